@@ -0,0 +1,129 @@
+// Copyright 2019 Montgomery Edwards⁴⁴⁸ and Faye Amacker
+
+package float16
+
+import (
+	"encoding/binary"
+)
+
+// ErrInvalidBinaryLength indicates UnmarshalBinary didn't receive exactly 2 bytes.
+const ErrInvalidBinaryLength = float16Error("float16: invalid binary length, expected 2 bytes")
+
+// MarshalBinary satisfies the encoding.BinaryMarshaler interface.  It returns
+// the 2 bytes of the IEEE 754 binary16 representation of f in big-endian
+// (network) byte order, so NaN payloads are preserved.
+func (f Float16) MarshalBinary() ([]byte, error) {
+	return AppendBigEndian(make([]byte, 0, 2), f), nil
+}
+
+// UnmarshalBinary satisfies the encoding.BinaryUnmarshaler interface.  It decodes
+// the 2 big-endian bytes produced by MarshalBinary and returns
+// ErrInvalidBinaryLength if data isn't exactly 2 bytes.
+func (f *Float16) UnmarshalBinary(data []byte) error {
+	if len(data) != 2 {
+		return ErrInvalidBinaryLength
+	}
+	*f = FromBigEndian(data)
+	return nil
+}
+
+// PutLittleEndian stores f into b[0:2] in little-endian byte order.
+// It panics if len(b) < 2, like binary.LittleEndian.PutUint16.
+func PutLittleEndian(b []byte, f Float16) {
+	_ = b[1] // bounds check hint to compiler; see golang.org/issue/14808
+	b[0] = byte(f)
+	b[1] = byte(f >> 8)
+}
+
+// PutBigEndian stores f into b[0:2] in big-endian byte order.
+// It panics if len(b) < 2, like binary.BigEndian.PutUint16.
+func PutBigEndian(b []byte, f Float16) {
+	_ = b[1] // bounds check hint to compiler; see golang.org/issue/14808
+	b[0] = byte(f >> 8)
+	b[1] = byte(f)
+}
+
+// AppendLittleEndian appends the 2 bytes of f in little-endian byte order to b
+// and returns the extended buffer.
+func AppendLittleEndian(b []byte, f Float16) []byte {
+	return append(b, byte(f), byte(f>>8))
+}
+
+// AppendBigEndian appends the 2 bytes of f in big-endian byte order to b
+// and returns the extended buffer.
+func AppendBigEndian(b []byte, f Float16) []byte {
+	return append(b, byte(f>>8), byte(f))
+}
+
+// FromLittleEndian returns the Float16 stored in b[0:2] in little-endian byte order.
+// It panics if len(b) < 2, like binary.LittleEndian.Uint16.
+func FromLittleEndian(b []byte) Float16 {
+	_ = b[1] // bounds check hint to compiler; see golang.org/issue/14808
+	return Float16(uint16(b[0]) | uint16(b[1])<<8)
+}
+
+// FromBigEndian returns the Float16 stored in b[0:2] in big-endian byte order.
+// It panics if len(b) < 2, like binary.BigEndian.Uint16.
+func FromBigEndian(b []byte) Float16 {
+	_ = b[1] // bounds check hint to compiler; see golang.org/issue/14808
+	return Float16(uint16(b[1]) | uint16(b[0])<<8)
+}
+
+// EncodeSlice stores the values of src into dst, 2 bytes each, in the specified
+// byte order.  Like copy, it encodes min(len(src), len(dst)/2) values and returns
+// that number.  binary.LittleEndian and binary.BigEndian use fast paths.
+func EncodeSlice(dst []byte, src []Float16, order binary.ByteOrder) int {
+	n := len(dst) / 2
+	if len(src) < n {
+		n = len(src)
+	}
+	dst, src = dst[:2*n], src[:n]
+	switch order {
+	case binary.LittleEndian:
+		for i, f := range src {
+			d := dst[2*i : 2*i+2]
+			d[0] = byte(f)
+			d[1] = byte(f >> 8)
+		}
+	case binary.BigEndian:
+		for i, f := range src {
+			d := dst[2*i : 2*i+2]
+			d[0] = byte(f >> 8)
+			d[1] = byte(f)
+		}
+	default:
+		for i, f := range src {
+			order.PutUint16(dst[2*i:], uint16(f))
+		}
+	}
+	return n
+}
+
+// DecodeSlice loads 2-byte values in the specified byte order from src into dst.
+// Like copy, it decodes min(len(dst), len(src)/2) values and returns that number.
+// A trailing odd byte in src is ignored.  binary.LittleEndian and binary.BigEndian
+// use fast paths.
+func DecodeSlice(dst []Float16, src []byte, order binary.ByteOrder) int {
+	n := len(src) / 2
+	if len(dst) < n {
+		n = len(dst)
+	}
+	dst, src = dst[:n], src[:2*n]
+	switch order {
+	case binary.LittleEndian:
+		for i := range dst {
+			s := src[2*i : 2*i+2]
+			dst[i] = Float16(uint16(s[0]) | uint16(s[1])<<8)
+		}
+	case binary.BigEndian:
+		for i := range dst {
+			s := src[2*i : 2*i+2]
+			dst[i] = Float16(uint16(s[1]) | uint16(s[0])<<8)
+		}
+	default:
+		for i := range dst {
+			dst[i] = Float16(order.Uint16(src[2*i:]))
+		}
+	}
+	return n
+}
@@ -0,0 +1,152 @@
+// Copyright 2019 Montgomery Edwards⁴⁴⁸ and Faye Amacker
+
+package float16_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"testing"
+
+	"github.com/x448/float16"
+)
+
+// otherOrder is a binary.ByteOrder that isn't binary.LittleEndian or
+// binary.BigEndian, to test the generic paths of EncodeSlice and DecodeSlice.
+type otherOrder struct{ binary.ByteOrder }
+
+func TestMarshalBinary(t *testing.T) {
+	for i := 0; i < 0x10000; i++ {
+		f16 := float16.Frombits(uint16(i))
+		b, err := f16.MarshalBinary()
+		if err != nil || len(b) != 2 || binary.BigEndian.Uint16(b) != uint16(i) {
+			t.Fatalf("0x%04x: MarshalBinary returned %x, %v", i, b, err)
+		}
+		var got float16.Float16
+		if err := got.UnmarshalBinary(b); err != nil || got != f16 {
+			t.Fatalf("0x%04x: UnmarshalBinary(%x) returned 0x%04x, %v", i, b, got.Bits(), err)
+		}
+	}
+
+	for _, b := range [][]byte{nil, {0x3c}, {0x3c, 0x00, 0x00}} {
+		got := float16.Frombits(0x1234)
+		if err := got.UnmarshalBinary(b); err != float16.ErrInvalidBinaryLength {
+			t.Errorf("UnmarshalBinary(%x) returned error %v, wanted ErrInvalidBinaryLength", b, err)
+		}
+		if got.Bits() != 0x1234 {
+			t.Errorf("UnmarshalBinary(%x) modified value on error to 0x%04x", b, got.Bits())
+		}
+	}
+}
+
+func TestGob(t *testing.T) {
+	type weights struct {
+		Scale  float16.Float16
+		Values []float16.Float16
+	}
+	in := weights{Scale: float16.Frombits(0x7c01), Values: []float16.Float16{0x3c00, 0x8001, 0xfc00}}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(in); err != nil {
+		t.Fatalf("gob Encode returned error %v", err)
+	}
+	var out weights
+	if err := gob.NewDecoder(&buf).Decode(&out); err != nil {
+		t.Fatalf("gob Decode returned error %v", err)
+	}
+	if out.Scale != in.Scale || len(out.Values) != len(in.Values) {
+		t.Fatalf("gob round-trip returned %v, wanted %v", out, in)
+	}
+	for i := range in.Values {
+		if out.Values[i] != in.Values[i] {
+			t.Errorf("gob round-trip returned %v, wanted %v", out, in)
+		}
+	}
+}
+
+func TestByteOrder(t *testing.T) {
+	f16 := float16.Frombits(0x3c01)
+
+	b := make([]byte, 2)
+	float16.PutLittleEndian(b, f16)
+	if !bytes.Equal(b, []byte{0x01, 0x3c}) {
+		t.Errorf("PutLittleEndian stored %x, wanted 013c", b)
+	}
+	if got := float16.FromLittleEndian(b); got != f16 {
+		t.Errorf("FromLittleEndian(%x) returned 0x%04x", b, got.Bits())
+	}
+
+	float16.PutBigEndian(b, f16)
+	if !bytes.Equal(b, []byte{0x3c, 0x01}) {
+		t.Errorf("PutBigEndian stored %x, wanted 3c01", b)
+	}
+	if got := float16.FromBigEndian(b); got != f16 {
+		t.Errorf("FromBigEndian(%x) returned 0x%04x", b, got.Bits())
+	}
+
+	b = float16.AppendLittleEndian([]byte{0xff}, f16)
+	if !bytes.Equal(b, []byte{0xff, 0x01, 0x3c}) {
+		t.Errorf("AppendLittleEndian returned %x, wanted ff013c", b)
+	}
+	b = float16.AppendBigEndian([]byte{0xff}, f16)
+	if !bytes.Equal(b, []byte{0xff, 0x3c, 0x01}) {
+		t.Errorf("AppendBigEndian returned %x, wanted ff3c01", b)
+	}
+}
+
+func TestEncodeDecodeSlice(t *testing.T) {
+	src := make([]float16.Float16, 0x10000)
+	for i := range src {
+		src[i] = float16.Frombits(uint16(i))
+	}
+
+	orders := []binary.ByteOrder{binary.LittleEndian, binary.BigEndian, otherOrder{binary.LittleEndian}, otherOrder{binary.BigEndian}}
+	for _, order := range orders {
+		buf := make([]byte, 2*len(src))
+		if n := float16.EncodeSlice(buf, src, order); n != len(src) {
+			t.Errorf("%v: EncodeSlice returned %d, wanted %d", order, n, len(src))
+		}
+
+		want := new(bytes.Buffer)
+		_ = binary.Write(want, order, src)
+		if !bytes.Equal(buf, want.Bytes()) {
+			t.Errorf("%v: EncodeSlice differs from binary.Write", order)
+		}
+
+		dst := make([]float16.Float16, len(src))
+		if n := float16.DecodeSlice(dst, buf, order); n != len(src) {
+			t.Errorf("%v: DecodeSlice returned %d, wanted %d", order, n, len(src))
+		}
+		for i := range src {
+			if dst[i] != src[i] {
+				t.Fatalf("%v: DecodeSlice returned 0x%04x at %d, wanted 0x%04x", order, dst[i].Bits(), i, src[i].Bits())
+			}
+		}
+	}
+
+	// Lengths that differ behave like copy.
+	buf := []byte{0xaa, 0xaa, 0xaa, 0xaa, 0xaa}
+	if n := float16.EncodeSlice(buf, []float16.Float16{0x3c00, 0x4000, 0x4200}, binary.LittleEndian); n != 2 {
+		t.Errorf("EncodeSlice into 5 bytes returned %d, wanted 2", n)
+	}
+	if !bytes.Equal(buf, []byte{0x00, 0x3c, 0x00, 0x40, 0xaa}) {
+		t.Errorf("EncodeSlice into 5 bytes stored %x", buf)
+	}
+	if n := float16.EncodeSlice(buf, []float16.Float16{0x3c01}, binary.BigEndian); n != 1 {
+		t.Errorf("EncodeSlice of 1 value returned %d, wanted 1", n)
+	}
+	if !bytes.Equal(buf, []byte{0x3c, 0x01, 0x00, 0x40, 0xaa}) {
+		t.Errorf("EncodeSlice of 1 value stored %x", buf)
+	}
+
+	dst := []float16.Float16{0x1111, 0x1111, 0x1111}
+	if n := float16.DecodeSlice(dst, buf, binary.BigEndian); n != 2 {
+		t.Errorf("DecodeSlice from 5 bytes returned %d, wanted 2", n)
+	}
+	if dst[0] != 0x3c01 || dst[1] != 0x0040 || dst[2] != 0x1111 {
+		t.Errorf("DecodeSlice from 5 bytes returned %v", dst)
+	}
+	if n := float16.DecodeSlice(dst[:1], buf, binary.LittleEndian); n != 1 || dst[0] != 0x013c {
+		t.Errorf("DecodeSlice into 1 value returned %d, %v", n, dst)
+	}
+}
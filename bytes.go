@@ -0,0 +1,56 @@
+// Copyright 2019 Montgomery Edwards⁴⁴⁸ and Faye Amacker
+
+package float16
+
+import (
+	"encoding/binary"
+	"unsafe"
+)
+
+// ErrInvalidByteLength indicates BytesToFloat16s received an odd number of bytes.
+const ErrInvalidByteLength = float16Error("float16: invalid byte length, expected a multiple of 2")
+
+// hostLittleEndian is true if the CPU stores the low byte of a uint16 first.
+var hostLittleEndian = func() bool {
+	x := uint16(1)
+	return *(*byte)(unsafe.Pointer(&x)) == 1
+}()
+
+// BytesToFloat16s returns the little-endian Float16 values stored in b.
+// It returns ErrInvalidByteLength if len(b) is odd.
+//
+// If the host is little-endian and b is 2-byte aligned, the result shares
+// memory with b without copying, so changes to either are seen in the other.
+// Otherwise, such as on big-endian s390x and ppc64, the values are copied.
+func BytesToFloat16s(b []byte) ([]Float16, error) {
+	if len(b)%2 != 0 {
+		return nil, ErrInvalidByteLength
+	}
+	if len(b) == 0 {
+		return nil, nil
+	}
+	p := unsafe.Pointer(&b[0])
+	if hostLittleEndian && uintptr(p)%2 == 0 {
+		return unsafe.Slice((*Float16)(p), len(b)/2), nil
+	}
+	s := make([]Float16, len(b)/2)
+	DecodeSlice(s, b, binary.LittleEndian)
+	return s, nil
+}
+
+// Float16sToBytes returns the values of s as little-endian bytes.
+//
+// If the host is little-endian, the result shares memory with s without
+// copying, so changes to either are seen in the other.  Otherwise, such as on
+// big-endian s390x and ppc64, the values are copied.
+func Float16sToBytes(s []Float16) []byte {
+	if len(s) == 0 {
+		return nil
+	}
+	if hostLittleEndian {
+		return unsafe.Slice((*byte)(unsafe.Pointer(&s[0])), 2*len(s))
+	}
+	b := make([]byte, 2*len(s))
+	EncodeSlice(b, s, binary.LittleEndian)
+	return b
+}
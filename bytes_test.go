@@ -0,0 +1,75 @@
+// Copyright 2019 Montgomery Edwards⁴⁴⁸ and Faye Amacker
+
+package float16_test
+
+import (
+	"testing"
+	"unsafe"
+
+	"github.com/x448/float16"
+)
+
+func TestBytesToFloat16s(t *testing.T) {
+	for _, little := range []bool{true, false} {
+		func() {
+			defer float16.SetHostLittleEndian(little)()
+
+			buf := []byte{0xff, 0x00, 0x3c, 0x01, 0xc0, 0xff, 0x7b}
+			for _, b := range [][]byte{buf[:6], buf[1:]} {
+				s, err := float16.BytesToFloat16s(b)
+				if err != nil {
+					t.Fatalf("BytesToFloat16s(% x): %v", b, err)
+				}
+				want := []float16.Float16{float16.FromLittleEndian(b), float16.FromLittleEndian(b[2:]), float16.FromLittleEndian(b[4:])}
+				if len(s) != len(want) || cap(s) != len(want) {
+					t.Fatalf("BytesToFloat16s(% x) returned len %d cap %d, wanted %d", b, len(s), cap(s), len(want))
+				}
+				for i := range want {
+					if s[i] != want[i] {
+						t.Errorf("BytesToFloat16s(% x)[%d] = 0x%04x, wanted 0x%04x", b, i, s[i].Bits(), want[i].Bits())
+					}
+				}
+
+				// Only aligned bytes on a little-endian host are shared.
+				b[0]++
+				shared := s[0] != want[0]
+				b[0]--
+				if aligned := uintptr(unsafe.Pointer(&b[0]))%2 == 0; shared != (little && aligned) {
+					t.Errorf("BytesToFloat16s(% x) shared=%t with little=%t aligned=%t", b, shared, little, aligned)
+				}
+			}
+		}()
+	}
+}
+
+func TestBytesToFloat16sInvalid(t *testing.T) {
+	if s, err := float16.BytesToFloat16s(make([]byte, 3)); s != nil || err != float16.ErrInvalidByteLength {
+		t.Errorf("BytesToFloat16s(3 bytes) = %v, %v, wanted nil, %v", s, err, float16.ErrInvalidByteLength)
+	}
+	if s, err := float16.BytesToFloat16s([]byte{}); s != nil || err != nil {
+		t.Errorf("BytesToFloat16s(0 bytes) = %v, %v, wanted nil, nil", s, err)
+	}
+}
+
+func TestFloat16sToBytes(t *testing.T) {
+	for _, little := range []bool{true, false} {
+		func() {
+			defer float16.SetHostLittleEndian(little)()
+
+			s := []float16.Float16{0x3c00, 0x7bff, 0x0001}
+			b := float16.Float16sToBytes(s)
+			want := []byte{0x00, 0x3c, 0xff, 0x7b, 0x01, 0x00}
+			if string(b) != string(want) {
+				t.Fatalf("Float16sToBytes = % x, wanted % x", b, want)
+			}
+
+			s[0] = 0
+			if shared := b[1] == 0; shared != little {
+				t.Errorf("Float16sToBytes shared=%t with little=%t", shared, little)
+			}
+		}()
+	}
+	if b := float16.Float16sToBytes(nil); b != nil {
+		t.Errorf("Float16sToBytes(nil) = % x, wanted nil", b)
+	}
+}
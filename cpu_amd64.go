@@ -0,0 +1,63 @@
+// Copyright 2019 Montgomery Edwards⁴⁴⁸ and Faye Amacker
+
+//go:build !purego
+
+package float16
+
+// CPU features used by the amd64 batch kernels.  Each is true only if both
+// the CPU and the operating system support it.  They're set by a variable
+// initializer rather than init, so they're ready when kernels is initialized.
+//
+//   - hasF16C: AVX and F16C, for VCVTPS2PH and VCVTPH2PS with YMM registers
+//   - hasAVX512: AVX512F, for VCVTPS2PH and VCVTPH2PS with ZMM registers
+//   - hasAVX512FP16: AVX512-FP16, for VCVTPD2PH and VCVTPH2PD
+var hasF16C, hasAVX512, hasAVX512FP16 = cpuFeatures(cpuid, xgetbv)
+
+// cpuid executes the CPUID instruction with the specified EAX and ECX inputs.
+func cpuid(eaxArg, ecxArg uint32) (eax, ebx, ecx, edx uint32)
+
+// xgetbv returns the low 64 bits of XCR0, the extended control register
+// that shows which register states the operating system saves.
+func xgetbv() (eax, edx uint32)
+
+// cpuFeatures returns the values of hasF16C, hasAVX512 and hasAVX512FP16 from
+// the results of the CPUID and XGETBV instructions.
+func cpuFeatures(cpuid func(eaxArg, ecxArg uint32) (eax, ebx, ecx, edx uint32), xgetbv func() (eax, edx uint32)) (f16c, avx512, avx512fp16 bool) {
+	maxID, _, _, _ := cpuid(0, 0)
+	if maxID < 1 {
+		return false, false, false
+	}
+
+	_, _, ecx1, _ := cpuid(1, 0)
+	const (
+		cpuidOSXSAVE = 1 << 27
+		cpuidAVX     = 1 << 28
+		cpuidF16C    = 1 << 29
+	)
+	if ecx1&cpuidOSXSAVE == 0 {
+		return false, false, false
+	}
+
+	xcr0, _ := xgetbv()
+	const (
+		xcr0SSE    = 1 << 1
+		xcr0AVX    = 1 << 2
+		xcr0AVX512 = 1<<5 | 1<<6 | 1<<7 // opmask, upper ZMM0-15, ZMM16-31
+	)
+	osAVX := xcr0&(xcr0SSE|xcr0AVX) == xcr0SSE|xcr0AVX
+	osAVX512 := osAVX && xcr0&xcr0AVX512 == xcr0AVX512
+
+	f16c = osAVX && ecx1&cpuidAVX != 0 && ecx1&cpuidF16C != 0
+	if maxID < 7 || !f16c {
+		return f16c, false, false
+	}
+
+	_, ebx7, _, edx7 := cpuid(7, 0)
+	const (
+		cpuidAVX512F    = 1 << 16 // EBX
+		cpuidAVX512FP16 = 1 << 23 // EDX
+	)
+	avx512 = osAVX512 && ebx7&cpuidAVX512F != 0
+	avx512fp16 = avx512 && edx7&cpuidAVX512FP16 != 0
+	return f16c, avx512, avx512fp16
+}
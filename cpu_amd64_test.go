@@ -0,0 +1,59 @@
+// Copyright 2019 Montgomery Edwards⁴⁴⁸ and Faye Amacker
+
+//go:build !purego
+
+package float16_test
+
+import (
+	"testing"
+
+	"github.com/x448/float16"
+)
+
+func TestCPUFeatures(t *testing.T) {
+	const (
+		osxsave = 1 << 27
+		avx     = 1 << 28
+		f16c    = 1 << 29
+		xcrAVX  = 0x06
+		xcrZMM  = 0xe6
+		avx512f = 1 << 16
+		fp16    = 1 << 23
+	)
+	testCases := []struct {
+		name                    string
+		maxID, ecx1, ebx7, edx7 uint32
+		xcr0                    uint32
+		wantF16C, wantAVX512    bool
+		wantAVX512FP16          bool
+	}{
+		{"no leaf 1", 0, osxsave | avx | f16c, avx512f, fp16, xcrZMM, false, false, false},
+		{"no OSXSAVE", 7, avx | f16c, avx512f, fp16, xcrZMM, false, false, false},
+		{"no OS YMM", 7, osxsave | avx | f16c, avx512f, fp16, 0x02, false, false, false},
+		{"no F16C", 7, osxsave | avx, avx512f, fp16, xcrZMM, false, false, false},
+		{"no leaf 7", 1, osxsave | avx | f16c, avx512f, fp16, xcrZMM, true, false, false},
+		{"no OS ZMM", 7, osxsave | avx | f16c, avx512f, fp16, xcrAVX, true, false, false},
+		{"no AVX512F", 7, osxsave | avx | f16c, 0, fp16, xcrZMM, true, false, false},
+		{"no AVX512-FP16", 7, osxsave | avx | f16c, avx512f, 0, xcrZMM, true, true, false},
+		{"all", 7, osxsave | avx | f16c, avx512f, fp16, xcrZMM, true, true, true},
+	}
+	for _, tc := range testCases {
+		cpuid := func(eaxArg, ecxArg uint32) (eax, ebx, ecx, edx uint32) {
+			switch eaxArg {
+			case 0:
+				return tc.maxID, 0, 0, 0
+			case 1:
+				return 0, 0, tc.ecx1, 0
+			case 7:
+				return 0, tc.ebx7, 0, tc.edx7
+			}
+			t.Fatalf("%s: unexpected CPUID leaf %d", tc.name, eaxArg)
+			return 0, 0, 0, 0
+		}
+		xgetbv := func() (eax, edx uint32) { return tc.xcr0, 0 }
+		gotF16C, gotAVX512, gotAVX512FP16 := float16.CPUFeatures(cpuid, xgetbv)
+		if gotF16C != tc.wantF16C || gotAVX512 != tc.wantAVX512 || gotAVX512FP16 != tc.wantAVX512FP16 {
+			t.Errorf("%s: CPUFeatures returned %v, %v, %v, wanted %v, %v, %v", tc.name, gotF16C, gotAVX512, gotAVX512FP16, tc.wantF16C, tc.wantAVX512, tc.wantAVX512FP16)
+		}
+	}
+}
@@ -0,0 +1,8 @@
+// Copyright 2019 Montgomery Edwards⁴⁴⁸ and Faye Amacker
+
+//go:build !purego
+
+package float16
+
+// Export unexported functions for tests in package float16_test.
+var CPUFeatures = cpuFeatures
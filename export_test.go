@@ -0,0 +1,36 @@
+// Copyright 2019 Montgomery Edwards⁴⁴⁸ and Faye Amacker
+
+package float16
+
+// Export unexported functions for tests in package float16_test.
+var F64bitsToF16bits = f64bitsToF16bits
+
+// KernelNames returns the names of the batch kernels supported by the CPU.
+func KernelNames() []string {
+	names := make([]string, len(kernels))
+	for i, k := range kernels {
+		names[i] = k.name
+	}
+	return names
+}
+
+// UseKernel makes the batch conversions use the named kernel until restore
+// is called.
+func UseKernel(name string) (restore func()) {
+	saved := kernel
+	for _, k := range kernels {
+		if k.name == name {
+			kernel = k
+			return func() { kernel = saved }
+		}
+	}
+	panic("float16: unknown batch kernel " + name)
+}
+
+// SetHostLittleEndian makes BytesToFloat16s and Float16sToBytes act as if the
+// host had the specified byte order until restore is called.
+func SetHostLittleEndian(little bool) (restore func()) {
+	saved := hostLittleEndian
+	hostLittleEndian = little
+	return func() { hostLittleEndian = saved }
+}
@@ -0,0 +1,437 @@
+// Copyright 2019 Montgomery Edwards⁴⁴⁸ and Faye Amacker
+
+// Package exr reads and writes OpenEXR images with HALF (Float16) channels.
+//
+// It supports single-part scanline files that are uncompressed or
+// ZIP-compressed, with R, G, B and A channels.  Tiled, deep and multi-part
+// files and other compression methods aren't supported.  Images are
+// float16.RGBA64F16 values, so they work with image/draw.  See
+// https://openexr.com/en/latest/OpenEXRFileLayout.html for the format.
+package exr
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"image"
+	"io"
+	"math"
+
+	"github.com/x448/float16"
+)
+
+var (
+	// ErrFormat indicates data that isn't a valid OpenEXR file.
+	ErrFormat = errors.New("float16/exr: invalid OpenEXR file")
+
+	// ErrUnsupported indicates a valid OpenEXR file using features this
+	// package doesn't support, or an unsupported Compression passed to Encode.
+	ErrUnsupported = errors.New("float16/exr: unsupported OpenEXR feature")
+
+	// ErrEmpty indicates an image with no pixels passed to Encode.
+	ErrEmpty = errors.New("float16/exr: empty image")
+)
+
+// Compression is an OpenEXR compression method.
+type Compression uint8
+
+const (
+	None Compression = 0 // uncompressed
+	ZIPS Compression = 2 // zlib, one scanline per block
+	ZIP  Compression = 3 // zlib, 16 scanlines per block
+)
+
+// linesPerBlock is the number of scanlines in each block of a compression.
+var linesPerBlock = map[Compression]int{None: 1, ZIPS: 1, ZIP: 16}
+
+// magic starts every OpenEXR file.
+const magic = 20000630
+
+// Version field bits.
+const (
+	versionMask   = 0xff
+	version       = 2
+	flagTiled     = 0x200
+	flagDeep      = 0x800
+	flagMultipart = 0x1000
+)
+
+// Channel pixel types and their sizes.
+const (
+	pixelUint  = 0
+	pixelHalf  = 1
+	pixelFloat = 2
+)
+
+var pixelSizes = [...]int{pixelUint: 4, pixelHalf: 2, pixelFloat: 4}
+
+// maxPixels limits the size of decoded images.
+const maxPixels = 1 << 28
+
+// rgba maps the channel names this package reads and writes to their index in
+// a pixel of float16.RGBA64F16.Pix.  Files store channels sorted by name.
+var rgba = map[string]int{"R": 0, "G": 1, "B": 2, "A": 3}
+
+// channel is an entry of an OpenEXR channel list.
+type channel struct {
+	name      string
+	pixelType int32
+	xSampling int32
+	ySampling int32
+}
+
+// Decode reads an OpenEXR image from r.  The image's bounds are the file's
+// data window and, as in OpenEXR, its colors are premultiplied by alpha.
+// Channels other than R, G, B and A are ignored.  Missing color channels are
+// 0 and a missing A channel is 1.
+func Decode(r io.Reader) (*float16.RGBA64F16, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	d := &decoder{b: b}
+	if d.u32() != magic {
+		return nil, d.fail(ErrFormat)
+	}
+	if v := d.u32(); v&versionMask != version || v&(flagTiled|flagDeep|flagMultipart) != 0 {
+		return nil, d.fail(ErrUnsupported)
+	}
+
+	var (
+		channels    []channel
+		compression = -1
+		window      image.Rectangle
+		hasWindow   bool
+	)
+	for {
+		name := d.cstring()
+		if name == "" || d.err != nil {
+			break
+		}
+		typ := d.cstring()
+		a := &decoder{b: d.bytes(int(d.u32()))}
+		switch {
+		case name == "channels" && typ == "chlist":
+			for {
+				c := channel{name: a.cstring()}
+				if c.name == "" || a.err != nil {
+					break
+				}
+				c.pixelType = int32(a.u32())
+				a.bytes(4) // pLinear and reserved
+				c.xSampling, c.ySampling = int32(a.u32()), int32(a.u32())
+				channels = append(channels, c)
+			}
+		case name == "compression" && typ == "compression":
+			compression = int(a.u8())
+		case name == "dataWindow" && typ == "box2i":
+			x0, y0, x1, y1 := int32(a.u32()), int32(a.u32()), int32(a.u32()), int32(a.u32())
+			window, hasWindow = image.Rect(int(x0), int(y0), int(x1)+1, int(y1)+1), x1 >= x0 && y1 >= y0
+		}
+		if a.err != nil {
+			return nil, ErrFormat
+		}
+	}
+	if d.err != nil {
+		return nil, d.fail(d.err)
+	}
+	if len(channels) == 0 || compression < 0 || !hasWindow {
+		return nil, ErrFormat
+	}
+	lines, ok := linesPerBlock[Compression(compression)]
+	if !ok {
+		return nil, ErrUnsupported
+	}
+	pixelSize := 0
+	for _, c := range channels {
+		if c.pixelType < 0 || int(c.pixelType) >= len(pixelSizes) {
+			return nil, ErrFormat
+		}
+		if _, ok := rgba[c.name]; ok && c.pixelType != pixelHalf || c.xSampling != 1 || c.ySampling != 1 {
+			return nil, ErrUnsupported
+		}
+		pixelSize += pixelSizes[c.pixelType]
+	}
+	w, h := window.Dx(), window.Dy()
+	if w > maxPixels/h {
+		return nil, ErrUnsupported
+	}
+
+	m := float16.NewRGBA64F16(window)
+	if !hasChannel(channels, "A") {
+		for i := 3; i < len(m.Pix); i += 4 {
+			m.Pix[i] = 0x3c00
+		}
+	}
+
+	// Read the blocks in the order of the offset table.
+	blocks := (h + lines - 1) / lines
+	offsets := d.bytes(8 * blocks)
+	if d.err != nil {
+		return nil, d.fail(d.err)
+	}
+	raw := make([]byte, lines*w*pixelSize)
+	for i := 0; i < blocks; i++ {
+		off := binary.LittleEndian.Uint64(offsets[8*i:])
+		if off > uint64(len(b)) {
+			return nil, ErrFormat
+		}
+		c := &decoder{b: b[off:]}
+		y, size := int(int32(c.u32())), c.u32()
+		data := c.bytes(int(size))
+		if c.err != nil || y < window.Min.Y || y >= window.Max.Y || (y-window.Min.Y)%lines != 0 {
+			return nil, ErrFormat
+		}
+		// Blocks that don't compress are stored uncompressed.
+		n := minInt(lines, window.Max.Y-y)
+		block := data
+		if rawLen := n * w * pixelSize; len(data) != rawLen {
+			if len(data) > rawLen || compression == int(None) {
+				return nil, ErrFormat
+			}
+			block = raw[:rawLen]
+			if err := unzip(block, data); err != nil {
+				return nil, err
+			}
+		}
+
+		for row := y; row < y+n; row++ {
+			p := m.Pix[m.PixOffset(window.Min.X, row):]
+			for _, ch := range channels {
+				size := w * pixelSizes[ch.pixelType]
+				if j, ok := rgba[ch.name]; ok {
+					for x := 0; x < w; x++ {
+						p[4*x+j] = float16.FromLittleEndian(block[2*x:])
+					}
+				}
+				block = block[size:]
+			}
+		}
+	}
+	return m, nil
+}
+
+func hasChannel(channels []channel, name string) bool {
+	for _, c := range channels {
+		if c.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Encode writes m to w as an OpenEXR file with R, G, B and A HALF channels,
+// compressed with c.
+func Encode(w io.Writer, m *float16.RGBA64F16, c Compression) error {
+	lines, ok := linesPerBlock[c]
+	if !ok {
+		return ErrUnsupported
+	}
+	r := m.Rect
+	if r.Empty() {
+		return ErrEmpty
+	}
+
+	var e encoder
+	e.u32(magic)
+	e.u32(version)
+	var chlist encoder
+	for _, name := range []string{"A", "B", "G", "R"} {
+		chlist.cstring(name)
+		chlist.u32(pixelHalf)
+		chlist.u32(0) // pLinear and reserved
+		chlist.u32(1)
+		chlist.u32(1)
+	}
+	chlist.WriteByte(0)
+	var box encoder
+	for _, v := range []int{r.Min.X, r.Min.Y, r.Max.X - 1, r.Max.Y - 1} {
+		box.u32(uint32(v))
+	}
+	var one, center encoder
+	one.u32(math.Float32bits(1))
+	center.u32(0)
+	center.u32(0)
+	e.attr("channels", "chlist", chlist.Bytes())
+	e.attr("compression", "compression", []byte{byte(c)})
+	e.attr("dataWindow", "box2i", box.Bytes())
+	e.attr("displayWindow", "box2i", box.Bytes())
+	e.attr("lineOrder", "lineOrder", []byte{0}) // INCREASING_Y
+	e.attr("pixelAspectRatio", "float", one.Bytes())
+	e.attr("screenWindowCenter", "v2f", center.Bytes())
+	e.attr("screenWindowWidth", "float", one.Bytes())
+	e.WriteByte(0)
+
+	// Build the blocks to find their offsets.
+	width, height := r.Dx(), r.Dy()
+	blocks := make([][]byte, 0, (height+lines-1)/lines)
+	var z bytes.Buffer
+	zw := zlib.NewWriter(&z)
+	for y := r.Min.Y; y < r.Max.Y; y += lines {
+		n := minInt(lines, r.Max.Y-y)
+		block := make([]byte, 8, 8+n*width*8)
+		binary.LittleEndian.PutUint32(block, uint32(y))
+		for row := y; row < y+n; row++ {
+			p := m.Pix[m.PixOffset(r.Min.X, row):]
+			for _, j := range []int{3, 2, 1, 0} { // A, B, G, R
+				for x := 0; x < width; x++ {
+					block = float16.AppendLittleEndian(block, p[4*x+j])
+				}
+			}
+		}
+		if c != None {
+			z.Reset()
+			zw.Reset(&z)
+			zw.Write(zipPredict(block[8:]))
+			zw.Close()
+			if z.Len() < len(block)-8 {
+				block = append(block[:8], z.Bytes()...)
+			}
+		}
+		binary.LittleEndian.PutUint32(block[4:], uint32(len(block)-8))
+		blocks = append(blocks, block)
+	}
+
+	off := uint64(e.Len() + 8*len(blocks))
+	for _, block := range blocks {
+		e.u64(off)
+		off += uint64(len(block))
+	}
+	if _, err := w.Write(e.Bytes()); err != nil {
+		return err
+	}
+	for _, block := range blocks {
+		if _, err := w.Write(block); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// zipPredict returns b reordered and delta-encoded like OpenEXR does before
+// zlib compression: the bytes at even indexes precede those at odd indexes,
+// and then each byte is replaced by its difference from the previous one
+// plus 128.
+func zipPredict(b []byte) []byte {
+	t := make([]byte, len(b))
+	half := (len(b) + 1) / 2
+	for i, v := range b {
+		t[i/2+i%2*half] = v
+	}
+	for i := len(t) - 1; i > 0; i-- {
+		t[i] = t[i] - t[i-1] + 128
+	}
+	return t
+}
+
+// unzip decompresses data into block and reverses zipPredict.
+func unzip(block, data []byte) error {
+	zr, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return ErrFormat
+	}
+	t := make([]byte, len(block))
+	if _, err := io.ReadFull(zr, t); err != nil {
+		return ErrFormat
+	}
+	for i := 1; i < len(t); i++ {
+		t[i] += t[i-1] - 128
+	}
+	half := (len(t) + 1) / 2
+	for i := range block {
+		block[i] = t[i/2+i%2*half]
+	}
+	return nil
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// decoder reads little-endian values.  After an error, reads return zero
+// values and err holds the first error.
+type decoder struct {
+	b   []byte
+	err error
+}
+
+// fail returns err, or io.ErrUnexpectedEOF if the data ended early.
+func (d *decoder) fail(err error) error {
+	if d.err != nil {
+		return d.err
+	}
+	return err
+}
+
+func (d *decoder) bytes(n int) []byte {
+	if d.err == nil && (n < 0 || n > len(d.b)) {
+		d.err = io.ErrUnexpectedEOF
+	}
+	if d.err != nil {
+		return nil
+	}
+	b := d.b[:n:n]
+	d.b = d.b[n:]
+	return b
+}
+
+func (d *decoder) u8() uint8 {
+	if b := d.bytes(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (d *decoder) u32() uint32 {
+	if b := d.bytes(4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+	return 0
+}
+
+// cstring reads a NUL-terminated string.
+func (d *decoder) cstring() string {
+	if d.err != nil {
+		return ""
+	}
+	i := bytes.IndexByte(d.b, 0)
+	if i < 0 {
+		d.err = io.ErrUnexpectedEOF
+		return ""
+	}
+	s := string(d.b[:i])
+	d.b = d.b[i+1:]
+	return s
+}
+
+// encoder writes little-endian values.
+type encoder struct{ bytes.Buffer }
+
+func (e *encoder) u32(v uint32) {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], v)
+	e.Write(b[:])
+}
+
+func (e *encoder) u64(v uint64) {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], v)
+	e.Write(b[:])
+}
+
+func (e *encoder) cstring(s string) {
+	e.WriteString(s)
+	e.WriteByte(0)
+}
+
+// attr writes a header attribute.
+func (e *encoder) attr(name, typ string, value []byte) {
+	e.cstring(name)
+	e.cstring(typ)
+	e.u32(uint32(len(value)))
+	e.Write(value)
+}
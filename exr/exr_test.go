@@ -0,0 +1,259 @@
+// Copyright 2019 Montgomery Edwards⁴⁴⁸ and Faye Amacker
+
+package exr_test
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"image"
+	"io"
+	"reflect"
+	"testing"
+	"testing/iotest"
+
+	"github.com/x448/float16"
+	"github.com/x448/float16/exr"
+)
+
+// le returns the little-endian bytes of 32-bit values.
+func le(vs ...uint32) string {
+	b := make([]byte, 4*len(vs))
+	for i, v := range vs {
+		binary.LittleEndian.PutUint32(b[4*i:], v)
+	}
+	return string(b)
+}
+
+// attr returns a header attribute.
+func attr(name, typ, value string) string {
+	return name + "\x00" + typ + "\x00" + le(uint32(len(value))) + value
+}
+
+// chlist returns a channel list of HALF channels.
+func chlist(names ...string) string {
+	s := ""
+	for _, name := range names {
+		s += name + "\x00" + le(1, 0, 1, 1)
+	}
+	return s + "\x00"
+}
+
+// header returns the header of a scanline file with the specified channels,
+// compression and data window, up to the offset table.
+func header(channels string, c exr.Compression, x0, y0, x1, y1 int32) string {
+	return le(20000630, 2) +
+		attr("channels", "chlist", channels) +
+		attr("compression", "compression", string(rune(c))) +
+		attr("dataWindow", "box2i", le(uint32(x0), uint32(y0), uint32(x1), uint32(y1))) +
+		"\x00"
+}
+
+func testImage(r image.Rectangle) *float16.RGBA64F16 {
+	m := float16.NewRGBA64F16(r)
+	for i := range m.Pix {
+		m.Pix[i] = float16.Frombits(uint16(i*0x0123 + 0x3000))
+	}
+	return m
+}
+
+func TestEncodeNone(t *testing.T) {
+	m := float16.NewRGBA64F16(image.Rect(-1, 5, 1, 6))
+	m.SetRGBA64F16(-1, 5, float16.RGBA64F16Color{R: 0x3c00, G: 0x3800, B: 0x3400, A: 0x3c00})
+	m.SetRGBA64F16(0, 5, float16.RGBA64F16Color{R: 0x4000, G: 0x0001, B: 0x8000, A: 0x3800})
+
+	var buf bytes.Buffer
+	if err := exr.Encode(&buf, m, exr.None); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	window := le(0xffffffff, 5, 0, 5)
+	header := le(20000630, 2) +
+		attr("channels", "chlist", chlist("A", "B", "G", "R")) +
+		attr("compression", "compression", "\x00") +
+		attr("dataWindow", "box2i", window) +
+		attr("displayWindow", "box2i", window) +
+		attr("lineOrder", "lineOrder", "\x00") +
+		attr("pixelAspectRatio", "float", le(0x3f800000)) +
+		attr("screenWindowCenter", "v2f", le(0, 0)) +
+		attr("screenWindowWidth", "float", le(0x3f800000)) +
+		"\x00"
+	want := header + le(uint32(len(header)+8), 0) + le(5, 16) +
+		"\x00\x3c\x00\x38" + // A
+		"\x00\x34\x00\x80" + // B
+		"\x00\x38\x01\x00" + // G
+		"\x00\x3c\x00\x40" // R
+	if got := buf.String(); got != want {
+		t.Fatalf("Encode wrote\n%q\nwanted\n%q", got, want)
+	}
+
+	got, err := exr.Decode(&buf)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if !reflect.DeepEqual(got, m) {
+		t.Errorf("Decode = %+v, wanted %+v", got, m)
+	}
+}
+
+func TestEncodeZIP(t *testing.T) {
+	for _, c := range []exr.Compression{exr.None, exr.ZIPS, exr.ZIP} {
+		for _, r := range []image.Rectangle{
+			image.Rect(0, 0, 1, 1),
+			image.Rect(0, 0, 64, 40),
+			image.Rect(-10, -20, 7, 13),
+		} {
+			m := testImage(r)
+			var buf bytes.Buffer
+			if err := exr.Encode(&buf, m, c); err != nil {
+				t.Fatalf("Encode(%v, %d): %v", r, c, err)
+			}
+			got, err := exr.Decode(&buf)
+			if err != nil {
+				t.Fatalf("Decode(%v, %d): %v", r, c, err)
+			}
+			if !reflect.DeepEqual(got, m) {
+				t.Errorf("Decode(Encode(%v, %d)) differs", r, c)
+			}
+		}
+	}
+
+	// Constant images compress.
+	m := float16.NewRGBA64F16(image.Rect(0, 0, 64, 64))
+	var none, zip bytes.Buffer
+	exr.Encode(&none, m, exr.None)
+	exr.Encode(&zip, m, exr.ZIP)
+	if zip.Len() >= none.Len()/10 {
+		t.Errorf("ZIP wrote %d bytes, uncompressed %d", zip.Len(), none.Len())
+	}
+}
+
+// zipBlock compresses block like OpenEXR's ZIP compression.
+func zipBlock(block []byte) []byte {
+	var t []byte
+	for i := 0; i < len(block); i += 2 {
+		t = append(t, block[i])
+	}
+	for i := 1; i < len(block); i += 2 {
+		t = append(t, block[i])
+	}
+	p := t[0]
+	for i := 1; i < len(t); i++ {
+		p, t[i] = t[i], t[i]-p+128
+	}
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	zw.Write(t)
+	zw.Close()
+	return buf.Bytes()
+}
+
+func TestDecodeZIP(t *testing.T) {
+	// A 16x2 ZIPS image with an R channel and a FLOAT Z channel that
+	// Decode skips.
+	channels := "R\x00" + le(1, 0, 1, 1) + "Z\x00" + le(2, 0, 1, 1) + "\x00"
+	h := header(channels, exr.ZIPS, 0, 0, 15, 1)
+	var blocks [][]byte
+	for y := 0; y < 2; y++ {
+		var raw []byte
+		for x := 0; x < 16; x++ {
+			raw = append(raw, byte(x), 0x3c+byte(y))
+		}
+		raw = append(raw, make([]byte, 16*4)...)
+		z := zipBlock(raw)
+		blocks = append(blocks, []byte(le(uint32(y), uint32(len(z)))+string(z)))
+	}
+	file := h + le(uint32(len(h)+16), 0, uint32(len(h)+16+len(blocks[0])), 0) + string(blocks[0]) + string(blocks[1])
+
+	m, err := exr.Decode(bytes.NewReader([]byte(file)))
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 16; x++ {
+			want := float16.Frombits(uint16(0x3c+y)<<8 | uint16(x))
+			if c := m.RGBA64F16At(x, y); c != (float16.RGBA64F16Color{R: want, A: 0x3c00}) {
+				t.Errorf("RGBA64F16At(%d, %d) = %v, wanted {%v 0 0 1}", x, y, c, want)
+			}
+		}
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	var buf bytes.Buffer
+	exr.Encode(&buf, testImage(image.Rect(0, 0, 3, 2)), exr.None)
+	valid := buf.Bytes()
+	for n := 0; n < len(valid); n++ {
+		if _, err := exr.Decode(bytes.NewReader(valid[:n])); err == nil {
+			t.Fatalf("Decode(%d of %d bytes) = nil, wanted error", n, len(valid))
+		}
+	}
+	errRead := errors.New("read failed")
+	if _, err := exr.Decode(iotest.ErrReader(errRead)); err != errRead {
+		t.Errorf("Decode(ErrReader) = %v, wanted %v", err, errRead)
+	}
+
+	rgb := chlist("B", "G", "R")
+	h := header(rgb, exr.None, 0, 0, 0, 0)
+	block := le(0, 6) + "\x00\x3c\x00\x3c\x00\x3c"
+	table := le(uint32(len(h)+8), 0)
+	if _, err := exr.Decode(bytes.NewReader([]byte(h + table + block))); err != nil {
+		t.Fatalf("Decode of valid file: %v", err)
+	}
+	for _, tc := range []struct {
+		name string
+		file string
+		want error
+	}{
+		{"magic", le(20000631, 2), exr.ErrFormat},
+		{"version", le(20000630, 1), exr.ErrUnsupported},
+		{"tiled", le(20000630, 0x202), exr.ErrUnsupported},
+		{"multipart", le(20000630, 0x1002), exr.ErrUnsupported},
+		{"no channels", header("\x00", exr.None, 0, 0, 0, 0), exr.ErrFormat},
+		{"no compression", le(20000630, 2) + attr("channels", "chlist", rgb) + attr("dataWindow", "box2i", le(0, 0, 0, 0)) + "\x00", exr.ErrFormat},
+		{"empty window", header(rgb, exr.None, 0, 0, -1, 0), exr.ErrFormat},
+		{"short attribute", le(20000630, 2) + attr("compression", "compression", "") + "\x00", exr.ErrFormat},
+		{"short chlist", le(20000630, 2) + attr("channels", "chlist", "R\x00\x01") + "\x00", exr.ErrFormat},
+		{"RLE", header(rgb, 1, 0, 0, 0, 0), exr.ErrUnsupported},
+		{"pixel type", header("R\x00"+le(3, 0, 1, 1)+"\x00", exr.None, 0, 0, 0, 0), exr.ErrFormat},
+		{"FLOAT R", header("R\x00"+le(2, 0, 1, 1)+"\x00", exr.None, 0, 0, 0, 0), exr.ErrUnsupported},
+		{"subsampled", header("R\x00"+le(1, 0, 2, 2)+"\x00", exr.None, 0, 0, 0, 0), exr.ErrUnsupported},
+		{"huge", header(rgb, exr.None, 0, 0, 1<<16, 1<<16), exr.ErrUnsupported},
+		{"offset", h + le(1<<20, 0), exr.ErrFormat},
+		{"block y", h + table + le(1, 6) + block[8:], exr.ErrFormat},
+		{"block size", h + table + le(0, 7) + block[8:] + "\x00", exr.ErrFormat},
+		{"uncompressed block size", h + table + le(0, 4) + block[8:12], exr.ErrFormat},
+		{"zlib header", header(rgb, exr.ZIP, 0, 0, 0, 0) + table + le(0, 4) + "\x00\x00\x00\x00", exr.ErrFormat},
+		{"zlib data", header(rgb, exr.ZIP, 0, 0, 0, 0) + table + le(0, 5) + string(zipBlock([]byte{1, 2, 3, 4}))[:5], exr.ErrFormat},
+	} {
+		if _, err := exr.Decode(bytes.NewReader([]byte(tc.file))); err != tc.want {
+			t.Errorf("Decode(%s) = %v, wanted %v", tc.name, err, tc.want)
+		}
+	}
+}
+
+// failWriter fails after n successful writes.
+type failWriter struct{ n int }
+
+func (w *failWriter) Write(b []byte) (int, error) {
+	if w.n == 0 {
+		return 0, errors.New("write failed")
+	}
+	w.n--
+	return len(b), nil
+}
+
+func TestEncodeErrors(t *testing.T) {
+	m := testImage(image.Rect(0, 0, 2, 2))
+	if err := exr.Encode(io.Discard, m, 1); err != exr.ErrUnsupported {
+		t.Errorf("Encode(RLE) = %v, wanted %v", err, exr.ErrUnsupported)
+	}
+	if err := exr.Encode(io.Discard, float16.NewRGBA64F16(image.Rect(0, 0, 0, 5)), exr.None); err != exr.ErrEmpty {
+		t.Errorf("Encode(empty) = %v, wanted %v", err, exr.ErrEmpty)
+	}
+	for n := 0; n < 3; n++ {
+		if err := exr.Encode(&failWriter{n}, m, exr.None); err == nil {
+			t.Errorf("Encode failing after %d writes = nil, wanted error", n)
+		}
+	}
+}
@@ -114,6 +114,13 @@ func Fromfloat32(f32 float32) Float16 {
 	return Float16(f32bitsToF16bits(math.Float32bits(f32)))
 }
 
+// Fromfloat64 returns a Float16 value converted from f64. Conversion uses
+// IEEE default rounding (nearest int, with ties to even) directly from f64,
+// so it can differ from Fromfloat32(float32(f64)), which rounds twice.
+func Fromfloat64(f64 float64) Float16 {
+	return Float16(f64bitsToF16bits(math.Float64bits(f64)))
+}
+
 // ErrInvalidNaNValue indicates a NaN was not received.
 const ErrInvalidNaNValue = float16Error("float16: invalid NaN value, expected IEEE 754 NaN")
 
@@ -175,6 +182,12 @@ func (f Float16) Float32() float32 {
 	return math.Float32frombits(u32)
 }
 
+// Float64 returns a float64 converted from f (Float16).
+// This is a lossless conversion.
+func (f Float16) Float64() float64 {
+	return float64(f.Float32())
+}
+
 // Bits returns the IEEE 754 binary16 representation of f, with the sign bit
 // of f and the result in the same bit position. Bits(Frombits(x)) == x.
 func (f Float16) Bits() uint16 {
@@ -223,88 +236,104 @@ func (f Float16) String() string {
 }
 
 // f16bitsToF32bits returns uint32 (float32 bits) converted from specified uint16.
+// It doesn't branch, so it takes the same time for every class of input.
 func f16bitsToF32bits(in uint16) uint32 {
-	// All 65536 conversions with this were confirmed to be correct
-	// by Montgomery Edwards⁴⁴⁸ (github.com/x448).
-
-	sign := uint32(in&0x8000) << 16 // sign for 32-bit
-	exp := uint32(in&0x7c00) >> 10  // exponenent for 16-bit
-	coef := uint32(in&0x03ff) << 13 // significand for 32-bit
-
-	if exp == 0x1f {
-		if coef == 0 {
-			// infinity
-			return sign | 0x7f800000 | coef
-		}
-		// NaN
-		return sign | 0x7fc00000 | coef
+	// All 65536 conversions with this are checked by TestAllToFloat32.
+
+	x := uint32(in & 0x7fff) // exponent and significand
+
+	// Normal numbers rebias the exponent by 127-15, and infinity and NaN
+	// rebias it again to 255.  Subnormal numbers are x * 2**-24, which
+	// float32 represents exactly.  Both are computed so the compiler selects
+	// one with CMOV or CSEL.  NaN is quieted.
+	r := x<<13 + (0x7f-0xf)<<23
+	s := math.Float32bits(float32(x) * 0x1p-24)
+	if x < 0x0400 {
+		r = s
 	}
-
-	if exp == 0 {
-		if coef == 0 {
-			// zero
-			return sign
-		}
-
-		// normalize subnormal numbers
-		exp++
-		for coef&0x7f800000 == 0 {
-			coef <<= 1
-			exp--
-		}
-		coef &= 0x007fffff
+	if x >= 0x7c00 {
+		r += (0x7f - 0xf) << 23
 	}
-
-	return sign | ((exp + (0x7f - 0xf)) << 23) | coef
+	if x > 0x7c00 {
+		r |= 0x00400000
+	}
+	return r | uint32(in&0x8000)<<16
 }
 
 // f32bitsToF16bits returns uint16 (Float16 bits) converted from the specified float32.
-// Conversion rounds to nearest integer with ties to even.
+// Conversion rounds to nearest integer with ties to even.  It doesn't branch,
+// so it takes the same time for every class of input.
 func f32bitsToF16bits(u32 uint32) uint16 {
-	// Translated from Rust to Go by Montgomery Edwards⁴⁴⁸ (github.com/x448).
-	// All 4294967296 conversions with this were confirmed to be correct by x448.
-	// Original Rust implementation is by Kathryn Long (github.com/starkat99) with MIT license.
+	// All 4294967296 conversions with this are checked by TestAllFromFloat32.
+
+	a := u32 & 0x7fffffff // exponent and significand
+
+	// Normal numbers rebias the exponent by 127-15 and round at bit 13 by
+	// adding half minus 1 plus the lowest kept bit (0x37fff001 is
+	// (127-15)<<23 - 0xfff), which can carry into the exponent up to
+	// infinity.  Subnormal numbers are rounded by the FPU: adding 0.5
+	// leaves |f| * 2**24 rounded in the low bits, and uint16 drops the
+	// exponent of 0.5.  Both are computed so the compiler selects one with
+	// CMOV or CSEL, like the results for overflow and NaN.
+	r := (a - 0x37fff001 + a>>13&1) >> 13
+	s := math.Float32bits(math.Float32frombits(a) + 0.5)
+	if a < 0x38800000 {
+		r = s
+	}
+	if a >= 0x47800000 {
+		r = 0x7c00 // overflow or infinity
+	}
+	if a > 0x7f800000 {
+		r = 0x7e00 | a>>13&0x1ff // NaN is quieted and keeps the top of its payload
+	}
+	return uint16(u32>>16&0x8000 | r)
+}
 
-	sign := u32 & 0x80000000
-	exp := u32 & 0x7f800000
-	coef := u32 & 0x007fffff
+// f64bitsToF16bits returns uint16 (Float16 bits) converted from the specified float64.
+// Conversion rounds to nearest integer with ties to even.  NaN and subnormal handling
+// is the same as f32bitsToF16bits, so converting a float32 to float64 first gives the
+// same result as converting it directly.
+func f64bitsToF16bits(u64 uint64) uint16 {
+	sign := u64 & 0x8000000000000000
+	exp := u64 & 0x7ff0000000000000
+	coef := u64 & 0x000fffffffffffff
 
-	if exp == 0x7f800000 {
+	halfSign := uint16(sign >> 48)
+
+	if exp == 0x7ff0000000000000 {
 		// NaN or Infinity
-		nanBit := uint32(0)
+		nanBit := uint16(0)
 		if coef != 0 {
-			nanBit = uint32(0x0200)
+			nanBit = uint16(0x0200)
 		}
-		return uint16((sign >> 16) | uint32(0x7c00) | nanBit | (coef >> 13))
+		return halfSign | uint16(0x7c00) | nanBit | uint16(coef>>42)
 	}
 
-	halfSign := sign >> 16
-
-	unbiasedExp := int32(exp>>23) - 127
+	unbiasedExp := int32(exp>>52) - 1023
 	halfExp := unbiasedExp + 15
 
 	if halfExp >= 0x1f {
-		return uint16(halfSign | uint32(0x7c00))
+		return halfSign | uint16(0x7c00)
 	}
 
 	if halfExp <= 0 {
-		if 14-halfExp > 24 {
-			return uint16(halfSign)
+		if 43-halfExp > 53 {
+			return halfSign
 		}
-		c := coef | uint32(0x00800000)
-		halfCoef := c >> uint32(14-halfExp)
-		roundBit := uint32(1) << uint32(13-halfExp)
+		c := coef | uint64(0x0010000000000000)
+		halfCoef := c >> uint64(43-halfExp)
+		roundBit := uint64(1) << uint64(42-halfExp)
 		if (c&roundBit) != 0 && (c&(3*roundBit-1)) != 0 {
 			halfCoef++
 		}
-		return uint16(halfSign | halfCoef)
+		return halfSign | uint16(halfCoef)
 	}
 
-	uHalfExp := uint32(halfExp) << 10
-	halfCoef := coef >> 13
-	roundBit := uint32(0x00001000)
+	uHalfExp := uint16(halfExp) << 10
+	halfCoef := uint16(coef >> 42)
+	roundBit := uint64(0x0000020000000000)
 	if (coef&roundBit) != 0 && (coef&(3*roundBit-1)) != 0 {
-		return uint16((halfSign | uHalfExp | halfCoef) + 1)
+		return (halfSign | uHalfExp | halfCoef) + 1
 	}
-	return uint16(halfSign | uHalfExp | halfCoef)
+	return halfSign | uHalfExp | halfCoef
 }
@@ -4,6 +4,7 @@ package float16_test
 
 import (
 	"math"
+	"math/rand"
 	"testing"
 
 	"github.com/x448/float16"
@@ -26,6 +27,68 @@ func BenchmarkFloat32pi(b *testing.B) {
 	resultF32 = result
 }
 
+// benchValues returns 1024 Float16 values in random order.  If mixed is true,
+// they are normal, subnormal, zero, infinity and NaN in equal parts, so
+// branches on the class of input are mispredicted like with real data.
+// Otherwise they are all normal.
+func benchValues(mixed bool) []float16.Float16 {
+	rng := rand.New(rand.NewSource(35))
+	s := make([]float16.Float16, 1024)
+	for i := range s {
+		sign := uint16(rng.Intn(2)) << 15
+		exp := uint16(1 + rng.Intn(30))
+		if mixed {
+			exp = [...]uint16{exp, 0, 0, 31, 31}[rng.Intn(5)]
+		}
+		frac := uint16(rng.Intn(1 << 10))
+		if mixed && exp == 31 && frac != 0 && rng.Intn(2) == 0 {
+			frac = 0 // infinity
+		}
+		s[i] = float16.Frombits(sign | exp<<10 | frac)
+	}
+	return s
+}
+
+func benchFloat32(b *testing.B, mixed bool) {
+	src := benchValues(mixed)
+	result := float32(0)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		result = src[i&1023].Float32()
+	}
+	resultF32 = result
+}
+
+func BenchmarkFloat32normal(b *testing.B) { benchFloat32(b, false) }
+func BenchmarkFloat32mixed(b *testing.B)  { benchFloat32(b, true) }
+
+func benchFromFloat32(b *testing.B, mixed bool) {
+	values := benchValues(mixed)
+	src := make([]float32, len(values))
+	for i, f := range values {
+		// Add low bits so conversions round.
+		src[i] = math.Float32frombits(math.Float32bits(f.Float32()) | uint32(i)&0x1fff)
+	}
+	result := float16.Float16(0)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		result = float16.Fromfloat32(src[i&1023])
+	}
+	resultF16 = result
+}
+
+func BenchmarkFromFloat32normal(b *testing.B) { benchFromFloat32(b, false) }
+func BenchmarkFromFloat32mixed(b *testing.B)  { benchFromFloat32(b, true) }
+
+func BenchmarkTableDecoderFloat32(b *testing.B) {
+	result := float32(0)
+	d := float16.NewTableDecoder()
+	for i := 0; i < b.N; i++ {
+		result = d.Float32(float16.Frombits(uint16(i)))
+	}
+	resultF32 = result
+}
+
 func BenchmarkFrombits(b *testing.B) {
 	result := float16.Float16(0)
 	pi32 := float32(math.Pi)
@@ -86,3 +149,98 @@ func BenchmarkString(b *testing.B) {
 	}
 	resultStr = result
 }
+
+func BenchmarkAppendFormat(b *testing.B) {
+	buf := make([]byte, 0, 32)
+
+	pi16 := float16.Fromfloat32(float32(math.Pi))
+	for i := 0; i < b.N; i++ {
+		buf = pi16.AppendFormat(buf[:0], 'g', -1)
+	}
+	resultStr = string(buf)
+}
+
+func BenchmarkMarshalJSONSlice(b *testing.B) {
+	src := make([]float16.Float16, 1024)
+	for i := range src {
+		src[i] = float16.Fromfloat32(float32(i) / 7)
+	}
+	b.SetBytes(int64(len(src) * 2))
+	for i := 0; i < b.N; i++ {
+		buf, _ := float16.MarshalJSONSlice(src)
+		resultStr = string(buf[:1])
+	}
+}
+
+func BenchmarkFromFloat32s(b *testing.B) {
+	src := make([]float32, 4096)
+	for i := range src {
+		src[i] = float32(i) * float32(math.Pi)
+	}
+	dst := make([]float16.Float16, len(src))
+	b.Run("loop", func(b *testing.B) {
+		b.SetBytes(int64(len(src) * 4))
+		for i := 0; i < b.N; i++ {
+			for j, f32 := range src {
+				dst[j] = float16.Fromfloat32(f32)
+			}
+		}
+	})
+	for _, name := range float16.KernelNames() {
+		b.Run(name, func(b *testing.B) {
+			defer float16.UseKernel(b.Name()[len("BenchmarkFromFloat32s/"):])()
+			b.SetBytes(int64(len(src) * 4))
+			for i := 0; i < b.N; i++ {
+				float16.FromFloat32s(dst, src)
+			}
+		})
+	}
+	resultF16 = dst[0]
+}
+
+func BenchmarkToFloat32s(b *testing.B) {
+	src := make([]float16.Float16, 4096)
+	for i := range src {
+		src[i] = float16.Frombits(uint16(i * 16))
+	}
+	dst := make([]float32, len(src))
+	b.Run("loop", func(b *testing.B) {
+		b.SetBytes(int64(len(src) * 2))
+		for i := 0; i < b.N; i++ {
+			for j, f16 := range src {
+				dst[j] = f16.Float32()
+			}
+		}
+	})
+	for _, name := range float16.KernelNames() {
+		b.Run(name, func(b *testing.B) {
+			defer float16.UseKernel(b.Name()[len("BenchmarkToFloat32s/"):])()
+			b.SetBytes(int64(len(src) * 2))
+			for i := 0; i < b.N; i++ {
+				float16.ToFloat32s(dst, src)
+			}
+		})
+	}
+	b.Run("table", func(b *testing.B) {
+		d := float16.NewTableDecoder()
+		b.SetBytes(int64(len(src) * 2))
+		for i := 0; i < b.N; i++ {
+			d.ToFloat32s(dst, src)
+		}
+	})
+	resultF32 = dst[0]
+}
+
+func BenchmarkUnaryTableApply(b *testing.B) {
+	tab := float16.NewUnaryTable(math.Tanh)
+	src := make([]float16.Float16, 4096)
+	for i := range src {
+		src[i] = float16.Frombits(uint16(i * 16))
+	}
+	dst := make([]float16.Float16, len(src))
+	b.SetBytes(int64(len(src) * 2))
+	for i := 0; i < b.N; i++ {
+		tab.Apply(dst, src)
+	}
+	resultF16 = dst[0]
+}
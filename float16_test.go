@@ -345,6 +345,43 @@ func TestFromNaN32ps(t *testing.T) {
 
 }
 
+// Test f64bitsToF16bits gives the same results as f32bitsToF16bits for
+// float32 inputs, plus inputs that only float64 can represent.
+func TestF64bitsToF16bits(t *testing.T) {
+	for i, v := range wantF32toF16bits {
+		u16 := float16.F64bitsToF16bits(math.Float64bits(float64(v.in)))
+		if u16 != v.out {
+			t.Errorf("i=%d, in f32bits=0x%08x, wanted=0x%04x, got=0x%04x.", i, math.Float32bits(v.in), v.out, u16)
+		}
+	}
+
+	wantF64toF16bits := []struct {
+		in  uint64
+		out uint16
+	}{
+		{in: 0x3ff0020000000000, out: 0x3c00}, // 1 + 2**-11, halfway ties to even
+		{in: 0x3ff0020000000001, out: 0x3c01}, // just above halfway
+		{in: 0x3ff0060000000000, out: 0x3c02}, // 1 + 3*2**-11, halfway ties to even
+		{in: 0x3e60000000000000, out: 0x0000}, // 2**-25, halfway ties to even
+		{in: 0x3e60000000000001, out: 0x0001}, // just above 2**-25
+		{in: 0x3e5fffffffffffff, out: 0x0000}, // just below 2**-25
+		{in: 0x0000000000000001, out: 0x0000}, // smallest float64 subnormal
+		{in: 0x40effe0000000000, out: 0x7c00}, // 65520 rounds to +Inf
+		{in: 0x40effdffffffffff, out: 0x7bff}, // just below 65520
+		{in: 0x7ff0000000000000, out: 0x7c00}, // +Inf
+		{in: 0xfff0000000000000, out: 0xfc00}, // -Inf
+		{in: 0x7ff8000000000001, out: 0x7e00}, // math.NaN()
+		{in: 0x7ff0000000000001, out: 0x7e00}, // sNaN with low payload is quieted
+		{in: 0xfff4000000000000, out: 0xff00}, // -sNaN with high payload is quieted
+	}
+	for i, v := range wantF64toF16bits {
+		u16 := float16.F64bitsToF16bits(v.in)
+		if u16 != v.out {
+			t.Errorf("i=%d, in f64bits=0x%016x, wanted=0x%04x, got=0x%04x.", i, v.in, v.out, u16)
+		}
+	}
+}
+
 // Test a small subset of possible conversions from float32 to Float16.
 // TestSomeFromFloat32 runs in under 1 second while TestAllFromFloat32 takes about 45 seconds.
 func TestSomeFromFloat32(t *testing.T) {
@@ -410,6 +447,22 @@ func TestAllFromFloat32(t *testing.T) {
 	}
 }
 
+// Test f64bitsToF16bits gives the same result as Fromfloat32 for all
+// 4294967296 float32 input values converted to float64.
+func TestAllF64bitsToF16bits(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping TestAllF64bitsToF16bits in short mode.")
+	}
+
+	for i := uint64(0); i <= uint64(0xFFFFFFFF); i++ {
+		inF32 := math.Float32frombits(uint32(i))
+		want := uint16(float16.Fromfloat32(inF32))
+		if u16 := float16.F64bitsToF16bits(math.Float64bits(float64(inF32))); u16 != want {
+			t.Fatalf("in f32bits=0x%08x, wanted=0x%04x, got=0x%04x.", uint32(i), want, u16)
+		}
+	}
+}
+
 // Test all 65536 conversions from float16 to float32.
 // TestAllToFloat32 runs in under 1 second.
 func TestAllToFloat32(t *testing.T) {
@@ -0,0 +1,284 @@
+// Copyright 2019 Montgomery Edwards⁴⁴⁸ and Faye Amacker
+
+package float16
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// float64pow10 holds the powers of ten that are exactly representable as float64.
+var float64pow10 = [...]float64{
+	1e0, 1e1, 1e2, 1e3, 1e4, 1e5, 1e6, 1e7, 1e8, 1e9,
+	1e10, 1e11, 1e12, 1e13, 1e14, 1e15, 1e16, 1e17, 1e18, 1e19,
+	1e20, 1e21, 1e22,
+}
+
+// uint64pow10 holds the powers of ten used by the shortest decimal search.
+var uint64pow10 = [...]uint64{
+	1e0, 1e1, 1e2, 1e3, 1e4, 1e5, 1e6, 1e7, 1e8, 1e9,
+	1e10, 1e11, 1e12, 1e13, 1e14, 1e15, 1e16, 1e17, 1e18, 1e19,
+}
+
+// AppendFormat appends the string form of f, as generated by fmt and prec,
+// to dst and returns the extended buffer.  The fmt and prec arguments have
+// the same meaning as in strconv.AppendFloat.  The special precision -1 uses
+// the smallest number of digits necessary for the result to round-trip
+// through binary16 (not float32).  AppendFormat doesn't allocate unless dst
+// needs to grow.
+func (f Float16) AppendFormat(dst []byte, fmt byte, prec int) []byte {
+	switch fmt {
+	case 'b':
+		return f.appendBinaryExp(dst)
+	case 'x', 'X':
+		return strconv.AppendFloat(dst, f.Float64(), fmt, prec, 64)
+	}
+	if prec < 0 {
+		return strconv.AppendFloat(dst, f.shortest(), fmt, -1, 64)
+	}
+	return strconv.AppendFloat(dst, f.Float64(), fmt, prec, 64)
+}
+
+// GoString satisfies the fmt.GoStringer interface and is used by the %#v verb.
+// It returns Go syntax that reproduces f exactly, such as float16.Frombits(0x3c00).
+func (f Float16) GoString() string {
+	const hex = "0123456789abcdef"
+	b := []byte("float16.Frombits(0x0000)")
+	b[19] = hex[f>>12&0xf]
+	b[20] = hex[f>>8&0xf]
+	b[21] = hex[f>>4&0xf]
+	b[22] = hex[f&0xf]
+	return string(b)
+}
+
+// Format satisfies the fmt.Formatter interface.  The floating-point verbs
+// %b, %e, %E, %f, %F, %g, %G, %x and %X, together with width, precision and
+// flags, behave as they do for float32 and float64.  Without a precision,
+// %g prints the shortest decimal that round-trips through binary16.
+// %v prints String() without a precision and behaves as %g with one, %s
+// prints String(), %#v prints GoString(), and the integer verbs
+// %c, %d, %o, %O and %U print the underlying uint16 bits.
+func (f Float16) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'e', 'E', 'f', 'F', 'g', 'G', 'x', 'X':
+		prec, ok := s.Precision()
+		if !ok {
+			prec = -1
+		}
+		v := f.Float64()
+		if prec < 0 && (verb == 'g' || verb == 'G') {
+			v = f.shortest()
+		}
+		fmt.Fprintf(s, formatDirective(s, verb), v)
+	case 'b':
+		var buf [24]byte
+		writePadded(s, f.appendBinaryExp(buf[:0]), f.IsFinite())
+	case 'v':
+		if s.Flag('#') {
+			_, _ = io.WriteString(s, f.GoString())
+			return
+		}
+		if _, ok := s.Precision(); ok {
+			fmt.Fprintf(s, formatDirective(s, verb), f.Float64())
+			return
+		}
+		writePadded(s, []byte(f.String()), f.IsFinite())
+	case 's', 'q':
+		fmt.Fprintf(s, formatDirective(s, verb), f.String())
+	case 'c', 'd', 'o', 'O', 'U':
+		fmt.Fprintf(s, formatDirective(s, verb), uint16(f))
+	default:
+		fmt.Fprintf(s, "%%!%c(float16.Float16=%s)", verb, f.String())
+	}
+}
+
+// appendBinaryExp appends f in the strconv 'b' format (-ddddp±ddd) using
+// the binary16 significand and exponent.
+func (f Float16) appendBinaryExp(dst []byte) []byte {
+	if !f.IsFinite() {
+		return strconv.AppendFloat(dst, f.Float64(), 'b', -1, 64)
+	}
+	if f.Signbit() {
+		dst = append(dst, '-')
+	}
+	coef := uint64(f & 0x03ff)
+	exp := int(f&0x7c00) >> 10
+	if exp == 0 {
+		exp = 1
+	} else {
+		coef |= 0x0400
+	}
+	exp -= 15 + 10
+	dst = strconv.AppendUint(dst, coef, 10)
+	dst = append(dst, 'p')
+	if exp >= 0 {
+		dst = append(dst, '+')
+	}
+	return strconv.AppendInt(dst, int64(exp), 10)
+}
+
+// shortest returns the float64 nearest to the shortest decimal that rounds
+// to f.  Formatting the result with precision -1 and bitSize 64 produces the
+// digits of that decimal.  Zero, infinity and NaN are returned unchanged.
+func (f Float16) shortest() float64 {
+	v := f.Float64()
+	if !f.IsFinite() || f&0x7fff == 0 {
+		return v
+	}
+
+	// Scale everything by 2**26 so f and the boundaries of the interval of
+	// values rounding to f are integers: f is a multiple of 2**-24 and the
+	// boundaries are multiples of 2**-25 (or 2**-26 below a power of two).
+	coef := uint64(f & 0x03ff)
+	exp := uint(f&0x7c00) >> 10
+	shift := exp
+	if exp == 0 {
+		shift = 1
+	} else {
+		coef |= 0x0400
+	}
+	x := (coef << shift) << 1
+	hi := x + uint64(1)<<shift
+	lo := x - uint64(1)<<shift
+	if coef == 0x0400 && exp > 1 {
+		// Below a power of two, the gap to the next smaller Float16 is halved.
+		lo = x - uint64(1)<<(shift-1)
+	}
+	inclusive := coef&1 == 0 // ties round to even
+
+	within := func(mant uint64, exp10 int) bool {
+		var d, l, h uint64
+		if exp10 >= 0 {
+			d, l, h = mant*uint64pow10[exp10]<<26, lo, hi
+		} else {
+			d, l, h = mant<<26, lo*uint64pow10[-exp10], hi*uint64pow10[-exp10]
+		}
+		if inclusive {
+			return l <= d && d <= h
+		}
+		return l < d && d < h
+	}
+
+	// Five significant digits are always enough to round-trip binary16,
+	// so the nearest 5-digit decimal is used if nothing shorter works.
+	var buf [32]byte
+	av := v
+	if av < 0 {
+		av = -av
+	}
+	for digits := 1; digits < 5; digits++ {
+		mant, exp10 := decimalParts(strconv.AppendFloat(buf[:0], av, 'e', digits-1, 64))
+		if within(mant, exp10) {
+			return decimalToFloat64(f.Signbit(), mant, exp10)
+		}
+		// Just below a power of two the interval is asymmetric, so the next
+		// decimal up can round-trip even when the nearest decimal doesn't.
+		if coef == 0x0400 && within(mant+1, exp10) {
+			return decimalToFloat64(f.Signbit(), mant+1, exp10)
+		}
+	}
+	mant, exp10 := decimalParts(strconv.AppendFloat(buf[:0], av, 'e', 4, 64))
+	return decimalToFloat64(f.Signbit(), mant, exp10)
+}
+
+// decimalParts parses the output of strconv.AppendFloat with format 'e' into
+// an integer mantissa and a power of ten.
+func decimalParts(b []byte) (mant uint64, exp10 int) {
+	i := 0
+	digits := 0
+	for ; i < len(b) && b[i] != 'e'; i++ {
+		if b[i] == '.' {
+			continue
+		}
+		mant = mant*10 + uint64(b[i]-'0')
+		digits++
+	}
+	neg := b[i+1] == '-'
+	for i += 2; i < len(b); i++ {
+		exp10 = exp10*10 + int(b[i]-'0')
+	}
+	if neg {
+		exp10 = -exp10
+	}
+	return mant, exp10 - (digits - 1)
+}
+
+// decimalToFloat64 returns the float64 nearest to mant * 10**exp10.
+// Both operands are exact, so the single multiplication or division
+// is correctly rounded.
+func decimalToFloat64(neg bool, mant uint64, exp10 int) float64 {
+	v := float64(mant)
+	if exp10 < 0 {
+		v /= float64pow10[-exp10]
+	} else {
+		v *= float64pow10[exp10]
+	}
+	if neg {
+		v = -v
+	}
+	return v
+}
+
+// formatDirective rebuilds the directive (such as "%-+8.3e") used to invoke Format.
+func formatDirective(s fmt.State, verb rune) string {
+	b := make([]byte, 0, 16)
+	b = append(b, '%')
+	for _, c := range " +-#0" {
+		if s.Flag(int(c)) {
+			b = append(b, byte(c))
+		}
+	}
+	if w, ok := s.Width(); ok {
+		b = strconv.AppendInt(b, int64(w), 10)
+	}
+	if p, ok := s.Precision(); ok {
+		b = append(b, '.')
+		b = strconv.AppendInt(b, int64(p), 10)
+	}
+	b = append(b, string(verb)...)
+	return string(b)
+}
+
+// writePadded writes num to s, honoring the width and the '+', ' ', '-'
+// and '0' flags in the same way fmt does for floating-point numbers.
+func writePadded(s fmt.State, num []byte, finite bool) {
+	if num[0] != '-' && num[0] != '+' {
+		if s.Flag('+') {
+			num = append([]byte{'+'}, num...)
+		} else if s.Flag(' ') {
+			num = append([]byte{' '}, num...)
+		}
+	}
+	width, ok := s.Width()
+	pad := width - len(num)
+	if !ok || pad <= 0 {
+		_, _ = s.Write(num)
+		return
+	}
+	padding := make([]byte, pad)
+	switch {
+	case s.Flag('-'):
+		for i := range padding {
+			padding[i] = ' '
+		}
+		_, _ = s.Write(num)
+		_, _ = s.Write(padding)
+	case s.Flag('0') && finite:
+		for i := range padding {
+			padding[i] = '0'
+		}
+		if num[0] == '-' || num[0] == '+' || num[0] == ' ' {
+			_, _ = s.Write(num[:1])
+			num = num[1:]
+		}
+		_, _ = s.Write(padding)
+		_, _ = s.Write(num)
+	default:
+		for i := range padding {
+			padding[i] = ' '
+		}
+		_, _ = s.Write(padding)
+		_, _ = s.Write(num)
+	}
+}
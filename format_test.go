@@ -0,0 +1,208 @@
+// Copyright 2019 Montgomery Edwards⁴⁴⁸ and Faye Amacker
+
+package float16_test
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/x448/float16"
+)
+
+// Test that verbs with an explicit precision (and %x without one) print
+// exactly what they print for the equivalent float32, for all 65536 values.
+func TestFormatMatchesFloat32(t *testing.T) {
+	directives := []string{
+		"%e", "%E", "%f", "%F", "%.3e", "%8.2f", "%-12.5g", "%+.4G",
+		"%x", "%X", "%+x", "%#.2X", "%08.3f", "% .1e", "%#.3g", "%20.10f",
+		"%.3v", "%+08.2v",
+	}
+	for i := 0; i < 0x10000; i++ {
+		f16 := float16.Frombits(uint16(i))
+		f32 := f16.Float32()
+		for _, d := range directives {
+			got := fmt.Sprintf(d, f16)
+			want := fmt.Sprintf(d, f32)
+			if got != want {
+				t.Fatalf("Sprintf(%q, 0x%04x) = %q, wanted %q", d, i, got, want)
+			}
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	testCases := []struct {
+		format string
+		in     interface{}
+		want   string
+	}{
+		{"%v", float16.Fromfloat32(0.1), "0.099975586"},
+		{"%s", float16.Fromfloat32(0.1), "0.099975586"},
+		{"%8s|", float16.Fromfloat32(1.5), "     1.5|"},
+		{"%g", float16.Fromfloat32(0.1), "0.1"},
+		{"%G", float16.Fromfloat32(3.141593), "3.14"},
+		{"%g", float16.Frombits(0x7bff), "65500"},
+		{"%g", float16.SmallestNonzero, "6e-08"},
+		{"%g", float16.Frombits(0x8000), "-0"},
+		{"%g", float16.Inf(-1), "-Inf"},
+		{"%+g", float16.Inf(1), "+Inf"},
+		{"%g", float16.NaN(), "NaN"},
+		{"%10g|", float16.Fromfloat32(0.1), "       0.1|"},
+		{"%-10g|", float16.Fromfloat32(0.1), "0.1       |"},
+		{"%#g", float16.SmallestNonzero, "6.00000e-08"},
+		{"%b", float16.Frombits(0x3c00), "1024p-10"},
+		{"%b", float16.Frombits(0x7bff), "2047p+5"},
+		{"%b", float16.Frombits(0x8001), "-1p-24"},
+		{"%b", float16.Frombits(0x0000), "0p-24"},
+		{"%b", float16.Inf(-1), "-Inf"},
+		{"%+b", float16.Frombits(0x3c00), "+1024p-10"},
+		{"% b", float16.Frombits(0x3c00), " 1024p-10"},
+		{"%010b", float16.Frombits(0xbc00), "-01024p-10"},
+		{"%-10b|", float16.Frombits(0x3c00), "1024p-10  |"},
+		{"%10b|", float16.Frombits(0x3c00), "  1024p-10|"},
+		{"%08b|", float16.NaN(), "     NaN|"},
+		{"%v", float16.Frombits(0x3c00), "1"},
+		{"%+v", float16.Frombits(0x3c00), "+1"},
+		{"%05v", float16.Frombits(0xbc00), "-0001"},
+		{"%#v", float16.Frombits(0x3c00), "float16.Frombits(0x3c00)"},
+		{"%#v", float16.Frombits(0xfbff), "float16.Frombits(0xfbff)"},
+		{"%#v", []float16.Float16{0x3c00, 0x7e01}, "[]float16.Float16{float16.Frombits(0x3c00), float16.Frombits(0x7e01)}"},
+		{"%d", float16.Frombits(0x3c00), "15360"},
+		{"%6o", float16.Frombits(0x0008), "    10"},
+		{"%U", float16.Frombits(0x0041), "U+0041"},
+		{"%q", float16.Frombits(0x3c00), `"1"`},
+		{"%t", float16.Frombits(0x3c00), "%!t(float16.Float16=1)"},
+	}
+	for _, tc := range testCases {
+		got := fmt.Sprintf(tc.format, tc.in)
+		if got != tc.want {
+			t.Errorf("Sprintf(%q, %#v) = %q, wanted %q", tc.format, tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestGoString(t *testing.T) {
+	f16 := float16.Frombits(0x3c00)
+	if s := f16.GoString(); s != "float16.Frombits(0x3c00)" {
+		t.Errorf("Float16(0x3c00).GoString() returned %s, wanted float16.Frombits(0x3c00)", s)
+	}
+}
+
+// Test that AppendFormat with precision -1 produces the shortest decimal
+// that rounds back to the same Float16, for all finite values.
+func TestAppendFormatShortest(t *testing.T) {
+	for i := 0; i < 0x10000; i++ {
+		f16 := float16.Frombits(uint16(i))
+		if !f16.IsFinite() || f16.Bits()&0x7fff == 0 {
+			continue
+		}
+		lo, hi, inclusive := roundingInterval(f16)
+
+		s := string(f16.AppendFormat(nil, 'e', -1))
+		r, ok := new(big.Rat).SetString(s)
+		if !ok {
+			t.Fatalf("0x%04x: AppendFormat returned unparsable %q", i, s)
+		}
+		if !withinInterval(r, lo, hi, inclusive) {
+			t.Errorf("0x%04x: AppendFormat returned %q, which doesn't round-trip", i, s)
+		}
+
+		// No decimal with fewer digits may round-trip.
+		digits := len(strings.TrimLeft(s[:strings.IndexByte(s, 'e')], "-"))
+		if digits > 1 {
+			digits-- // decimal point
+		}
+		if digits > 1 {
+			n, _ := strconv.ParseFloat(s, 64)
+			near := strconv.FormatFloat(math.Abs(n), 'e', digits-2, 64)
+			mant, _ := new(big.Rat).SetString(near[:strings.IndexByte(near, 'e')])
+			exp, _ := strconv.Atoi(near[strings.IndexByte(near, 'e')+1:])
+			unit := new(big.Rat).SetFrac(big.NewInt(1), new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(digits-2)), nil))
+			scale := new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(exp))), nil))
+			if exp < 0 {
+				scale.Inv(scale)
+			}
+			for _, delta := range []int64{-1, 0, 1} {
+				c := new(big.Rat).Add(mant, new(big.Rat).Mul(unit, big.NewRat(delta, 1)))
+				c.Mul(c, scale)
+				if f16.Signbit() {
+					c.Neg(c)
+				}
+				if withinInterval(c, lo, hi, inclusive) {
+					t.Errorf("0x%04x: AppendFormat returned %q, but %s is shorter", i, s, c.FloatString(20))
+				}
+			}
+		}
+	}
+}
+
+func TestAppendFormat(t *testing.T) {
+	testCases := []struct {
+		in   float16.Float16
+		fmt  byte
+		prec int
+		want string
+	}{
+		{float16.Fromfloat32(0.1), 'g', -1, "0.1"},
+		{float16.Fromfloat32(0.1), 'f', -1, "0.1"},
+		{float16.Fromfloat32(0.1), 'e', 3, "9.998e-02"},
+		{float16.Fromfloat32(0.1), 'x', -1, "0x1.998p-04"},
+		{float16.Frombits(0x0400), 'g', -1, "6.104e-05"},
+		{float16.Frombits(0x3c00), 'b', -1, "1024p-10"},
+		{float16.Frombits(0x7c00), 'g', -1, "+Inf"},
+		{float16.Frombits(0x3c00), 'z', -1, "%z"},
+	}
+	for _, tc := range testCases {
+		got := string(tc.in.AppendFormat([]byte("x="), tc.fmt, tc.prec))
+		if got != "x="+tc.want {
+			t.Errorf("%#v.AppendFormat(%q, %d) = %q, wanted %q", tc.in, tc.fmt, tc.prec, got, "x="+tc.want)
+		}
+	}
+
+	buf := make([]byte, 0, 64)
+	f16 := float16.Fromfloat32(0.1)
+	allocs := testing.AllocsPerRun(100, func() {
+		buf = f16.AppendFormat(buf[:0], 'g', -1)
+	})
+	if allocs != 0 {
+		t.Errorf("AppendFormat allocated %v times, wanted 0", allocs)
+	}
+}
+
+// roundingInterval returns the exact interval of reals that round to f16.
+func roundingInterval(f16 float16.Float16) (lo, hi *big.Rat, inclusive bool) {
+	v := float64(f16.Float32())
+	prev := float64(float16.Frombits(f16.Bits() - 1).Float32())
+	next := float64(float16.Frombits(f16.Bits() + 1).Float32())
+	if f16.Bits()&0x7fff == 0x0001 {
+		// the neighbor toward zero is zero itself
+		prev = 0
+	}
+	if f16.Bits()&0x7fff == 0x7bff {
+		next = math.Copysign(65536, v)
+	}
+	lo = new(big.Rat).SetFloat64((prev + v) / 2)
+	hi = new(big.Rat).SetFloat64((v + next) / 2)
+	if lo.Cmp(hi) > 0 {
+		lo, hi = hi, lo
+	}
+	return lo, hi, f16.Bits()&1 == 0
+}
+
+func abs(i int) int {
+	if i < 0 {
+		return -i
+	}
+	return i
+}
+
+func withinInterval(r, lo, hi *big.Rat, inclusive bool) bool {
+	if inclusive {
+		return lo.Cmp(r) <= 0 && r.Cmp(hi) <= 0
+	}
+	return lo.Cmp(r) < 0 && r.Cmp(hi) < 0
+}
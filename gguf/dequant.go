@@ -0,0 +1,325 @@
+// Copyright 2019 Montgomery Edwards⁴⁴⁸ and Faye Amacker
+
+package gguf
+
+import (
+	"encoding/binary"
+	"math"
+	"strconv"
+
+	"github.com/x448/float16"
+)
+
+// Type is a GGML tensor type.
+type Type uint32
+
+// GGML tensor types.  Types 4 and 5 were removed from GGML.
+const (
+	TypeF32     Type = 0
+	TypeF16     Type = 1
+	TypeQ4_0    Type = 2
+	TypeQ4_1    Type = 3
+	TypeQ5_0    Type = 6
+	TypeQ5_1    Type = 7
+	TypeQ8_0    Type = 8
+	TypeQ8_1    Type = 9
+	TypeQ2_K    Type = 10
+	TypeQ3_K    Type = 11
+	TypeQ4_K    Type = 12
+	TypeQ5_K    Type = 13
+	TypeQ6_K    Type = 14
+	TypeQ8_K    Type = 15
+	TypeIQ2_XXS Type = 16
+	TypeIQ2_XS  Type = 17
+	TypeIQ3_XXS Type = 18
+	TypeIQ1_S   Type = 19
+	TypeIQ4_NL  Type = 20
+	TypeIQ3_S   Type = 21
+	TypeIQ2_S   Type = 22
+	TypeIQ4_XS  Type = 23
+	TypeI8      Type = 24
+	TypeI16     Type = 25
+	TypeI32     Type = 26
+	TypeI64     Type = 27
+	TypeF64     Type = 28
+	TypeIQ1_M   Type = 29
+	TypeBF16    Type = 30
+)
+
+var typeNames = map[Type]string{
+	TypeF32: "F32", TypeF16: "F16", TypeQ4_0: "Q4_0", TypeQ4_1: "Q4_1",
+	TypeQ5_0: "Q5_0", TypeQ5_1: "Q5_1", TypeQ8_0: "Q8_0", TypeQ8_1: "Q8_1",
+	TypeQ2_K: "Q2_K", TypeQ3_K: "Q3_K", TypeQ4_K: "Q4_K", TypeQ5_K: "Q5_K",
+	TypeQ6_K: "Q6_K", TypeQ8_K: "Q8_K", TypeIQ2_XXS: "IQ2_XXS", TypeIQ2_XS: "IQ2_XS",
+	TypeIQ3_XXS: "IQ3_XXS", TypeIQ1_S: "IQ1_S", TypeIQ4_NL: "IQ4_NL", TypeIQ3_S: "IQ3_S",
+	TypeIQ2_S: "IQ2_S", TypeIQ4_XS: "IQ4_XS", TypeI8: "I8", TypeI16: "I16",
+	TypeI32: "I32", TypeI64: "I64", TypeF64: "F64", TypeIQ1_M: "IQ1_M", TypeBF16: "BF16",
+}
+
+// String returns the GGML name of t, such as "Q4_K".
+func (t Type) String() string {
+	if name, ok := typeNames[t]; ok {
+		return name
+	}
+	return "Type(" + strconv.Itoa(int(t)) + ")"
+}
+
+// qk is the number of values in a block of the K-quant types.
+const qk = 256
+
+// typeLayout describes how a type stores blocks of values.
+type typeLayout struct {
+	blockLen  int                         // values per block
+	blockSize int                         // bytes per block
+	dequant   func(y []float32, b []byte) // converts one block, if supported
+}
+
+var layouts = map[Type]typeLayout{
+	TypeF32:  {1, 4, func(y []float32, b []byte) { y[0] = math.Float32frombits(binary.LittleEndian.Uint32(b)) }},
+	TypeF16:  {1, 2, func(y []float32, b []byte) { y[0] = f16(b) }},
+	TypeBF16: {1, 2, func(y []float32, b []byte) { y[0] = math.Float32frombits(uint32(binary.LittleEndian.Uint16(b)) << 16) }},
+	TypeF64:  {1, 8, func(y []float32, b []byte) { y[0] = float32(math.Float64frombits(binary.LittleEndian.Uint64(b))) }},
+	TypeQ4_0: {32, 18, dequantQ4_0},
+	TypeQ4_1: {32, 20, dequantQ4_1},
+	TypeQ5_0: {32, 22, dequantQ5_0},
+	TypeQ5_1: {32, 24, dequantQ5_1},
+	TypeQ8_0: {32, 34, dequantQ8_0},
+	TypeQ8_1: {32, 36, nil},
+	TypeQ2_K: {qk, 84, dequantQ2_K},
+	TypeQ3_K: {qk, 110, dequantQ3_K},
+	TypeQ4_K: {qk, 144, dequantQ4_K},
+	TypeQ5_K: {qk, 176, dequantQ5_K},
+	TypeQ6_K: {qk, 210, dequantQ6_K},
+	TypeQ8_K: {qk, 292, nil},
+	TypeI8:   {1, 1, nil},
+	TypeI16:  {1, 2, nil},
+	TypeI32:  {1, 4, nil},
+	TypeI64:  {1, 8, nil},
+}
+
+// Dequantize converts the blocks of type t in src to float32 values in dst.
+// Like copy, it converts min(len(dst), values in src) values, rounded down
+// to whole blocks, and returns that number.  It returns ErrType if t can't be
+// dequantized.  Results are identical to GGML's reference dequantization.
+func Dequantize(dst []float32, src []byte, t Type) (int, error) {
+	l, ok := layouts[t]
+	if !ok || l.dequant == nil {
+		return 0, ErrType
+	}
+	blocks := len(src) / l.blockSize
+	if n := len(dst) / l.blockLen; n < blocks {
+		blocks = n
+	}
+	for i := 0; i < blocks; i++ {
+		l.dequant(dst[i*l.blockLen:(i+1)*l.blockLen], src[i*l.blockSize:(i+1)*l.blockSize])
+	}
+	return blocks * l.blockLen, nil
+}
+
+// DequantizeFloat16s is like Dequantize, but converts the float32 values to
+// Float16 like float16.Fromfloat32.  F16 values are copied unchanged.
+func DequantizeFloat16s(dst []float16.Float16, src []byte, t Type) (int, error) {
+	if t == TypeF16 {
+		return float16.DecodeSlice(dst, src, binary.LittleEndian), nil
+	}
+	l, ok := layouts[t]
+	if !ok || l.dequant == nil {
+		return 0, ErrType
+	}
+	var buf [qk * 4]float32
+	chunk := len(buf) / l.blockLen * l.blockLen
+	bytesPerChunk := chunk / l.blockLen * l.blockSize
+	n := 0
+	for len(dst)-n >= l.blockLen && len(src) >= l.blockSize {
+		s := src
+		if len(s) > bytesPerChunk {
+			s = s[:bytesPerChunk]
+		}
+		k, _ := Dequantize(buf[:minInt(chunk, len(dst)-n)], s, t)
+		n += float16.FromFloat32s(dst[n:], buf[:k])
+		src = src[k/l.blockLen*l.blockSize:]
+	}
+	return n, nil
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// f16 returns the little-endian Float16 in b[0:2] as float32.
+func f16(b []byte) float32 {
+	return float16.FromLittleEndian(b).Float32()
+}
+
+// The dequant functions follow GGML's dequantize_row functions, including
+// the order of float32 operations.  Explicit float32 conversions keep Go from
+// fusing multiplies and adds, which GGML's reference code doesn't do.
+
+func dequantQ4_0(y []float32, b []byte) {
+	d, qs := f16(b), b[2:18]
+	for j, q := range qs {
+		y[j] = float32(int(q&0xf)-8) * d
+		y[j+16] = float32(int(q>>4)-8) * d
+	}
+}
+
+func dequantQ4_1(y []float32, b []byte) {
+	d, m, qs := f16(b), f16(b[2:]), b[4:20]
+	for j, q := range qs {
+		y[j] = float32(float32(q&0xf)*d) + m
+		y[j+16] = float32(float32(q>>4)*d) + m
+	}
+}
+
+func dequantQ5_0(y []float32, b []byte) {
+	d, qh, qs := f16(b), binary.LittleEndian.Uint32(b[2:]), b[6:22]
+	for j, q := range qs {
+		xh0 := byte(qh>>uint(j)<<4) & 0x10
+		xh1 := byte(qh>>uint(j+12)) & 0x10
+		y[j] = float32(int(q&0xf|xh0)-16) * d
+		y[j+16] = float32(int(q>>4|xh1)-16) * d
+	}
+}
+
+func dequantQ5_1(y []float32, b []byte) {
+	d, m, qh, qs := f16(b), f16(b[2:]), binary.LittleEndian.Uint32(b[4:]), b[8:24]
+	for j, q := range qs {
+		xh0 := byte(qh>>uint(j)<<4) & 0x10
+		xh1 := byte(qh>>uint(j+12)) & 0x10
+		y[j] = float32(float32(q&0xf|xh0)*d) + m
+		y[j+16] = float32(float32(q>>4|xh1)*d) + m
+	}
+}
+
+func dequantQ8_0(y []float32, b []byte) {
+	d, qs := f16(b), b[2:34]
+	for j, q := range qs {
+		y[j] = float32(int8(q)) * d
+	}
+}
+
+func dequantQ2_K(y []float32, b []byte) {
+	scales, q, d, min := b[0:16], b[16:80], f16(b[80:]), f16(b[82:])
+	is := 0
+	for n := 0; n < qk; n += 128 {
+		for shift := uint(0); shift < 8; shift += 2 {
+			for _, off := range []int{0, 16} {
+				sc := scales[is]
+				is++
+				dl, ml := d*float32(sc&0xf), min*float32(sc>>4)
+				for l := 0; l < 16; l++ {
+					y[0] = float32(dl*float32(q[off+l]>>shift&3)) - ml
+					y = y[1:]
+				}
+			}
+		}
+		q = q[32:]
+	}
+}
+
+func dequantQ3_K(y []float32, b []byte) {
+	hm, q, d := b[0:32], b[32:96], f16(b[108:])
+
+	// Unpack 16 6-bit scales from 12 bytes.
+	const kmask1, kmask2 = 0x03030303, 0x0f0f0f0f
+	a0, a1, tmp := binary.LittleEndian.Uint32(b[96:]), binary.LittleEndian.Uint32(b[100:]), binary.LittleEndian.Uint32(b[104:])
+	aux := [4]uint32{
+		a0&kmask2 | (tmp>>0&kmask1)<<4,
+		a1&kmask2 | (tmp>>2&kmask1)<<4,
+		a0>>4&kmask2 | (tmp>>4&kmask1)<<4,
+		a1>>4&kmask2 | (tmp>>6&kmask1)<<4,
+	}
+	var scales [16]int8
+	for i := range scales {
+		scales[i] = int8(aux[i/4] >> (8 * uint(i%4)))
+	}
+
+	is, m := 0, byte(1)
+	for n := 0; n < qk; n += 128 {
+		for shift := uint(0); shift < 8; shift += 2 {
+			for _, off := range []int{0, 16} {
+				dl := d * float32(int(scales[is])-32)
+				is++
+				for l := off; l < off+16; l++ {
+					v := int(q[l] >> shift & 3)
+					if hm[l]&m == 0 {
+						v -= 4
+					}
+					y[0] = dl * float32(v)
+					y = y[1:]
+				}
+			}
+			m <<= 1
+		}
+		q = q[32:]
+	}
+}
+
+// scaleMinK4 returns the j-th 6-bit scale and min packed in the 12 bytes of q,
+// like GGML's get_scale_min_k4.
+func scaleMinK4(j int, q []byte) (sc, m byte) {
+	if j < 4 {
+		return q[j] & 63, q[j+4] & 63
+	}
+	return q[j+4]&0xf | q[j-4]>>6<<4, q[j+4]>>4 | q[j]>>6<<4
+}
+
+func dequantQ4_K(y []float32, b []byte) {
+	d, min, scales, q := f16(b), f16(b[2:]), b[4:16], b[16:144]
+	for is := 0; is < 8; is += 2 {
+		sc, m := scaleMinK4(is, scales)
+		d1, m1 := d*float32(sc), min*float32(m)
+		sc, m = scaleMinK4(is+1, scales)
+		d2, m2 := d*float32(sc), min*float32(m)
+		for l := 0; l < 32; l++ {
+			y[l] = float32(d1*float32(q[l]&0xf)) - m1
+			y[l+32] = float32(d2*float32(q[l]>>4)) - m2
+		}
+		y, q = y[64:], q[32:]
+	}
+}
+
+func dequantQ5_K(y []float32, b []byte) {
+	d, min, scales, qh, ql := f16(b), f16(b[2:]), b[4:16], b[16:48], b[48:176]
+	u1, u2 := byte(1), byte(2)
+	for is := 0; is < 8; is += 2 {
+		sc, m := scaleMinK4(is, scales)
+		d1, m1 := d*float32(sc), min*float32(m)
+		sc, m = scaleMinK4(is+1, scales)
+		d2, m2 := d*float32(sc), min*float32(m)
+		for l := 0; l < 32; l++ {
+			q1, q2 := ql[l]&0xf, ql[l]>>4
+			if qh[l]&u1 != 0 {
+				q1 += 16
+			}
+			if qh[l]&u2 != 0 {
+				q2 += 16
+			}
+			y[l] = float32(d1*float32(q1)) - m1
+			y[l+32] = float32(d2*float32(q2)) - m2
+		}
+		y, ql = y[64:], ql[32:]
+		u1, u2 = u1<<2, u2<<2
+	}
+}
+
+func dequantQ6_K(y []float32, b []byte) {
+	ql, qh, sc, d := b[0:128], b[128:192], b[192:208], f16(b[208:])
+	for n := 0; n < qk; n += 128 {
+		for l := 0; l < 32; l++ {
+			is := l / 16
+			q1 := int(ql[l]&0xf|qh[l]>>0&3<<4) - 32
+			q2 := int(ql[l+32]&0xf|qh[l]>>2&3<<4) - 32
+			q3 := int(ql[l]>>4|qh[l]>>4&3<<4) - 32
+			q4 := int(ql[l+32]>>4|qh[l]>>6&3<<4) - 32
+			y[l] = d * float32(int8(sc[is])) * float32(q1)
+			y[l+32] = d * float32(int8(sc[is+2])) * float32(q2)
+			y[l+64] = d * float32(int8(sc[is+4])) * float32(q3)
+			y[l+96] = d * float32(int8(sc[is+6])) * float32(q4)
+		}
+		y, ql, qh, sc = y[128:], ql[64:], qh[32:], sc[8:]
+	}
+}
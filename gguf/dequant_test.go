@@ -0,0 +1,237 @@
+// Copyright 2019 Montgomery Edwards⁴⁴⁸ and Faye Amacker
+
+package gguf_test
+
+import (
+	"encoding/binary"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/x448/float16"
+	"github.com/x448/float16/gguf"
+)
+
+// Scales and mins are powers of two and quants are small integers, so every
+// dequantized value is exact in float32 and can be computed in float64.
+const (
+	scaleBits = 0x3400 // 0.25
+	scaleVal  = 0.25
+	minBits   = 0xb800 // -0.5
+	minVal    = -0.5
+)
+
+// block returns n random bytes with little-endian Float16 bits stored at the
+// offsets in halves.
+func block(rng *rand.Rand, n int, halves map[int]uint16) []byte {
+	b := make([]byte, n)
+	rng.Read(b)
+	for i, h := range halves {
+		binary.LittleEndian.PutUint16(b[i:], h)
+	}
+	return b
+}
+
+// bit returns bit i of the little-endian bytes in b.
+func bit(b []byte, i int) int {
+	return int(b[i/8] >> uint(i%8) & 1)
+}
+
+// nibble returns the low (hi == 0) or high (hi == 1) 4 bits of c.
+func nibble(c byte, hi int) int {
+	return int(c >> uint(4*hi) & 0xf)
+}
+
+// scaleMinK4 returns the 6-bit scale and min k packed in the 12 bytes of b.
+func scaleMinK4(b []byte, k int) (float64, float64) {
+	if k < 4 {
+		return float64(b[k] & 63), float64(b[k+4] & 63)
+	}
+	return float64(b[k+4]&0xf | b[k-4]>>6<<4), float64(b[k+4]>>4 | b[k]>>6<<4)
+}
+
+func TestDequantize(t *testing.T) {
+	rng := rand.New(rand.NewSource(44))
+	for _, tc := range []struct {
+		typ   gguf.Type
+		block []byte
+		want  func(b []byte, i int) float64
+	}{
+		{gguf.TypeQ4_0, block(rng, 18, map[int]uint16{0: scaleBits}), func(b []byte, i int) float64 {
+			return float64(nibble(b[2+i%16], i/16)-8) * scaleVal
+		}},
+		{gguf.TypeQ4_1, block(rng, 20, map[int]uint16{0: scaleBits, 2: minBits}), func(b []byte, i int) float64 {
+			return float64(nibble(b[4+i%16], i/16))*scaleVal + minVal
+		}},
+		{gguf.TypeQ5_0, block(rng, 22, map[int]uint16{0: scaleBits}), func(b []byte, i int) float64 {
+			return float64(nibble(b[6+i%16], i/16)+16*bit(b[2:6], i)-16) * scaleVal
+		}},
+		{gguf.TypeQ5_1, block(rng, 24, map[int]uint16{0: scaleBits, 2: minBits}), func(b []byte, i int) float64 {
+			return float64(nibble(b[8+i%16], i/16)+16*bit(b[4:8], i))*scaleVal + minVal
+		}},
+		{gguf.TypeQ8_0, block(rng, 34, map[int]uint16{0: scaleBits}), func(b []byte, i int) float64 {
+			return float64(int8(b[2+i])) * scaleVal
+		}},
+		{gguf.TypeQ2_K, block(rng, 84, map[int]uint16{80: scaleBits, 82: minBits}), func(b []byte, i int) float64 {
+			n, j, half, l := i/128, i%128/32, i%32/16, i%16
+			sc := b[n*8+j*2+half]
+			q := b[16+n*32+half*16+l] >> uint(2*j) & 3
+			return scaleVal*float64(sc&0xf)*float64(q) - minVal*float64(sc>>4)
+		}},
+		{gguf.TypeQ3_K, block(rng, 110, map[int]uint16{108: scaleBits}), func(b []byte, i int) float64 {
+			n, j, half, l := i/128, i%128/32, i%32/16, i%16
+			k := n*8 + j*2 + half
+			lo := b[96+k%8] >> uint(4*(k/8)) & 0xf
+			hi := b[104+k%4] >> uint(2*(k/4)) & 3
+			q := int(b[32+n*32+half*16+l] >> uint(2*j) & 3)
+			if bit(b[half*16+l:], n*4+j) == 0 {
+				q -= 4
+			}
+			return scaleVal * float64(int(lo|hi<<4)-32) * float64(q)
+		}},
+		{gguf.TypeQ4_K, block(rng, 144, map[int]uint16{0: scaleBits, 2: minBits}), func(b []byte, i int) float64 {
+			g := i / 32
+			sc, m := scaleMinK4(b[4:16], g)
+			q := nibble(b[16+g/2*32+i%32], g%2)
+			return scaleVal*sc*float64(q) - minVal*m
+		}},
+		{gguf.TypeQ5_K, block(rng, 176, map[int]uint16{0: scaleBits, 2: minBits}), func(b []byte, i int) float64 {
+			g := i / 32
+			sc, m := scaleMinK4(b[4:16], g)
+			q := nibble(b[48+g/2*32+i%32], g%2) + 16*bit(b[16+i%32:], g)
+			return scaleVal*sc*float64(q) - minVal*m
+		}},
+		{gguf.TypeQ6_K, block(rng, 210, map[int]uint16{208: scaleBits}), func(b []byte, i int) float64 {
+			n, quarter, l := i/128, i%128/32, i%32
+			lo := nibble(b[n*64+quarter%2*32+l], quarter/2)
+			hi := int(b[128+n*32+l] >> uint(2*quarter) & 3)
+			sc := int8(b[192+n*8+quarter*2+l/16])
+			return scaleVal * float64(sc) * float64(lo|hi<<4-32)
+		}},
+	} {
+		n := 32
+		if len(tc.block) > 34 {
+			n = 256
+		}
+		// Two blocks, with room for part of a third value block.
+		src := append(append([]byte(nil), tc.block...), tc.block...)
+		dst := make([]float32, 2*n+n/2)
+		got, err := gguf.Dequantize(dst, src, tc.typ)
+		if got != 2*n || err != nil {
+			t.Fatalf("Dequantize(%v) = %d, %v, wanted %d, nil", tc.typ, got, err, 2*n)
+		}
+		for i := 0; i < 2*n; i++ {
+			if want := tc.want(tc.block, i%n); float64(dst[i]) != want {
+				t.Errorf("Dequantize(%v)[%d] = %v, wanted %v", tc.typ, i, dst[i], want)
+			}
+		}
+		if dst[2*n] != 0 {
+			t.Errorf("Dequantize(%v) wrote past the last whole block", tc.typ)
+		}
+
+		// dst limits the conversion to whole blocks too.
+		if got, _ := gguf.Dequantize(dst[:2*n-1], src, tc.typ); got != n {
+			t.Errorf("Dequantize(%v) with short dst = %d, wanted %d", tc.typ, got, n)
+		}
+	}
+}
+
+func TestDequantizeFloats(t *testing.T) {
+	f32, f64 := float32(-1.5), 0x1p-20
+	b := make([]byte, 16)
+	binary.LittleEndian.PutUint32(b, math.Float32bits(f32))
+	binary.LittleEndian.PutUint16(b[4:], 0x3c01)
+	binary.LittleEndian.PutUint16(b[6:], 0x3fc0) // BF16 1.5
+	binary.LittleEndian.PutUint64(b[8:], math.Float64bits(f64))
+	for _, tc := range []struct {
+		typ  gguf.Type
+		src  []byte
+		want float32
+	}{
+		{gguf.TypeF32, b[:4], f32},
+		{gguf.TypeF16, b[4:6], float16.Frombits(0x3c01).Float32()},
+		{gguf.TypeBF16, b[6:8], 1.5},
+		{gguf.TypeF64, b[8:], float32(f64)},
+	} {
+		var dst [2]float32
+		if n, err := gguf.Dequantize(dst[:], tc.src, tc.typ); n != 1 || err != nil || dst[0] != tc.want {
+			t.Errorf("Dequantize(%v) = %d, %v, %v, wanted 1, nil, %v", tc.typ, n, err, dst[0], tc.want)
+		}
+	}
+}
+
+func TestDequantizeFloat16s(t *testing.T) {
+	// 40 Q8_0 blocks are more values than DequantizeFloat16s converts at once.
+	rng := rand.New(rand.NewSource(448))
+	var src []byte
+	for i := 0; i < 40; i++ {
+		src = append(src, block(rng, 34, map[int]uint16{0: uint16(0x2000 + i*0x100)})...)
+	}
+	f32s := make([]float32, 40*32)
+	gguf.Dequantize(f32s, src, gguf.TypeQ8_0)
+
+	for _, l := range []int{0, 31, 32, 1000, 1024, 1100, 40 * 32, 41 * 32} {
+		dst := make([]float16.Float16, l)
+		n, err := gguf.DequantizeFloat16s(dst, src, gguf.TypeQ8_0)
+		if want := minInt(l, 40*32) / 32 * 32; n != want || err != nil {
+			t.Fatalf("DequantizeFloat16s(len %d) = %d, %v, wanted %d, nil", l, n, err, want)
+		}
+		for i := 0; i < n; i++ {
+			if want := float16.Fromfloat32(f32s[i]); dst[i] != want {
+				t.Fatalf("DequantizeFloat16s(len %d)[%d] = 0x%04x, wanted 0x%04x", l, i, dst[i].Bits(), want.Bits())
+			}
+		}
+	}
+
+	// F16 values are copied unchanged, including NaN payloads.
+	dst := make([]float16.Float16, 2)
+	if n, err := gguf.DequantizeFloat16s(dst, []byte{0x01, 0x7c, 0x00, 0x3c, 0xff}, gguf.TypeF16); n != 2 || err != nil || dst[0] != 0x7c01 || dst[1] != 0x3c00 {
+		t.Errorf("DequantizeFloat16s(F16) = %d, %v, %v", n, err, dst)
+	}
+}
+
+func TestDequantizeErrors(t *testing.T) {
+	for _, typ := range []gguf.Type{gguf.TypeQ8_1, gguf.TypeQ8_K, gguf.TypeIQ2_XXS, gguf.TypeI32, 99} {
+		if n, err := gguf.Dequantize(make([]float32, 256), make([]byte, 1024), typ); n != 0 || err != gguf.ErrType {
+			t.Errorf("Dequantize(%v) = %d, %v, wanted 0, %v", typ, n, err, gguf.ErrType)
+		}
+		if n, err := gguf.DequantizeFloat16s(make([]float16.Float16, 256), make([]byte, 1024), typ); n != 0 || err != gguf.ErrType {
+			t.Errorf("DequantizeFloat16s(%v) = %d, %v, wanted 0, %v", typ, n, err, gguf.ErrType)
+		}
+	}
+}
+
+func TestTypeString(t *testing.T) {
+	for typ, want := range map[gguf.Type]string{
+		gguf.TypeF16:  "F16",
+		gguf.TypeQ4_K: "Q4_K",
+		gguf.TypeBF16: "BF16",
+		4:             "Type(4)",
+	} {
+		if got := typ.String(); got != want {
+			t.Errorf("Type(%d).String() = %q, wanted %q", uint32(typ), got, want)
+		}
+	}
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func BenchmarkDequantizeFloat16s(b *testing.B) {
+	rng := rand.New(rand.NewSource(0))
+	src := make([]byte, 144*64)
+	rng.Read(src)
+	for i := 0; i < len(src); i += 144 {
+		binary.LittleEndian.PutUint16(src[i:], scaleBits)
+		binary.LittleEndian.PutUint16(src[i+2:], minBits)
+	}
+	dst := make([]float16.Float16, 256*64)
+	b.SetBytes(int64(len(src)))
+	for i := 0; i < b.N; i++ {
+		gguf.DequantizeFloat16s(dst, src, gguf.TypeQ4_K)
+	}
+}
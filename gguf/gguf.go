@@ -0,0 +1,336 @@
+// Copyright 2019 Montgomery Edwards⁴⁴⁸ and Faye Amacker
+
+// Package gguf reads tensors from GGUF files, the model format of llama.cpp
+// and GGML, and dequantizes GGML's block formats.
+//
+// Most GGML block formats store a Float16 scale per block of quantized values,
+// which this package decodes with github.com/x448/float16.  See
+// https://github.com/ggerganov/ggml/blob/master/docs/gguf.md for the format.
+package gguf
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/x448/float16"
+)
+
+var (
+	// ErrFormat indicates data that isn't a valid GGUF file.
+	ErrFormat = errors.New("float16/gguf: invalid GGUF file")
+
+	// ErrVersion indicates a GGUF version other than 2 or 3.
+	ErrVersion = errors.New("float16/gguf: unsupported GGUF version")
+
+	// ErrNotFound indicates a tensor name that isn't in the file.
+	ErrNotFound = errors.New("float16/gguf: tensor not found")
+
+	// ErrType indicates a tensor type that can't be dequantized.
+	ErrType = errors.New("float16/gguf: unsupported tensor type")
+)
+
+// magic starts every GGUF file.
+const magic = "GGUF"
+
+// defaultAlignment is the alignment of tensor data if the file doesn't set
+// general.alignment.
+const defaultAlignment = 32
+
+// maxDims is GGML_MAX_DIMS, the most dimensions a tensor can have.
+const maxDims = 4
+
+// maxLen limits strings, arrays and counts in a header, so a
+// corrupt header fails instead of allocating too much memory.
+const maxLen = 1 << 30
+
+// TensorInfo describes a tensor in a GGUF file.
+type TensorInfo struct {
+	Name   string
+	Dims   []uint64 // with the fastest-varying dimension first, as in GGML
+	Type   Type
+	Offset int64 // of the data from the start of the file
+	Size   int64 // of the data in bytes, or -1 if Type is unknown
+}
+
+// Len returns the number of values in the tensor.
+func (t TensorInfo) Len() int64 {
+	n := int64(1)
+	for _, d := range t.Dims {
+		n *= int64(d)
+	}
+	return n
+}
+
+// A File is a GGUF file opened for reading.
+type File struct {
+	Version uint32
+
+	// Metadata holds the key-value pairs of the header.  Values are uint8,
+	// int8, uint16, int16, uint32, int32, uint64, int64, float32, float64,
+	// bool, string, or []interface{} of those for arrays.
+	Metadata map[string]interface{}
+
+	tensors []TensorInfo // sorted by name
+	r       io.ReaderAt
+}
+
+// Open reads the header of a GGUF file from r.  Tensor data is read from r
+// when requested, so r must stay open while the File is used.
+func Open(r io.ReaderAt) (*File, error) {
+	h := &headerReader{r: bufio.NewReader(io.NewSectionReader(r, 0, math.MaxInt64))}
+	if m := h.bytes(4); h.err == nil && string(m) != magic {
+		return nil, ErrFormat
+	}
+	f := &File{Version: h.u32(), Metadata: map[string]interface{}{}, r: r}
+	if h.err == nil && f.Version != 2 && f.Version != 3 {
+		return nil, ErrVersion
+	}
+	tensorCount, kvCount := h.count(), h.count()
+	for i := 0; i < kvCount && h.err == nil; i++ {
+		key := h.str()
+		f.Metadata[key] = h.value(h.u32(), 0)
+	}
+	if h.err != nil {
+		return nil, h.fail(h.err)
+	}
+
+	alignment := int64(defaultAlignment)
+	if a, ok := f.Metadata["general.alignment"]; ok {
+		a, ok := a.(uint32)
+		if !ok || a == 0 || a&(a-1) != 0 {
+			return nil, ErrFormat
+		}
+		alignment = int64(a)
+	}
+
+	for i := 0; i < tensorCount && h.err == nil; i++ {
+		t := TensorInfo{Name: h.str()}
+		nDims := h.u32()
+		if nDims > maxDims {
+			return nil, ErrFormat
+		}
+		for j := uint32(0); j < nDims && h.err == nil; j++ {
+			t.Dims = append(t.Dims, h.u64())
+		}
+		t.Type = Type(h.u32())
+		t.Offset = int64(h.u64())
+		t.Size = -1
+		if l, ok := layouts[t.Type]; ok {
+			n := uint64(1)
+			for _, d := range t.Dims {
+				if d != 0 && n > math.MaxInt/d {
+					return nil, ErrFormat
+				}
+				n *= d
+			}
+			if n%uint64(l.blockLen) != 0 || n/uint64(l.blockLen) > math.MaxInt/uint64(l.blockSize) {
+				return nil, ErrFormat
+			}
+			t.Size = int64(n) / int64(l.blockLen) * int64(l.blockSize)
+		}
+		if t.Offset < 0 || t.Offset%alignment != 0 {
+			return nil, ErrFormat
+		}
+		f.tensors = append(f.tensors, t)
+	}
+	if h.err != nil {
+		return nil, h.fail(h.err)
+	}
+
+	// Tensor offsets are from the aligned end of the header.
+	data := (h.n + alignment - 1) &^ (alignment - 1)
+	for i := range f.tensors {
+		f.tensors[i].Offset += data
+	}
+	sort.Slice(f.tensors, func(i, j int) bool { return f.tensors[i].Name < f.tensors[j].Name })
+	return f, nil
+}
+
+// Tensors returns the file's tensors sorted by name.
+func (f *File) Tensors() []TensorInfo {
+	return f.tensors
+}
+
+// Tensor returns the named tensor and reports whether it's in the file.
+func (f *File) Tensor(name string) (TensorInfo, bool) {
+	i := sort.Search(len(f.tensors), func(i int) bool { return f.tensors[i].Name >= name })
+	if i < len(f.tensors) && f.tensors[i].Name == name {
+		return f.tensors[i], true
+	}
+	return TensorInfo{}, false
+}
+
+// Bytes reads the data of the named tensor.
+func (f *File) Bytes(name string) ([]byte, error) {
+	t, ok := f.Tensor(name)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if t.Size < 0 {
+		return nil, ErrType
+	}
+	b := make([]byte, t.Size)
+	if _, err := f.r.ReadAt(b, t.Offset); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return b, nil
+}
+
+// Float16s reads the named tensor and returns its values as Float16.  F16
+// tensors are returned unchanged.  Other types are dequantized like
+// DequantizeFloat16s.
+func (f *File) Float16s(name string) ([]float16.Float16, error) {
+	t, b, err := f.read(name)
+	if err != nil {
+		return nil, err
+	}
+	s := make([]float16.Float16, t.Len())
+	DequantizeFloat16s(s, b, t.Type)
+	return s, nil
+}
+
+// Float32s reads the named tensor and returns its values dequantized like
+// Dequantize.
+func (f *File) Float32s(name string) ([]float32, error) {
+	t, b, err := f.read(name)
+	if err != nil {
+		return nil, err
+	}
+	s := make([]float32, t.Len())
+	Dequantize(s, b, t.Type)
+	return s, nil
+}
+
+// read returns the named tensor's info and data if its type can be dequantized.
+func (f *File) read(name string) (TensorInfo, []byte, error) {
+	t, ok := f.Tensor(name)
+	if !ok {
+		return t, nil, ErrNotFound
+	}
+	if l := layouts[t.Type]; l.dequant == nil {
+		return t, nil, ErrType
+	}
+	b, err := f.Bytes(name)
+	return t, b, err
+}
+
+// headerReader reads little-endian GGUF header fields.  After an error,
+// reads return zero values and err holds the first error.
+type headerReader struct {
+	r   *bufio.Reader
+	n   int64 // bytes read
+	err error
+}
+
+// fail returns err, or io.ErrUnexpectedEOF instead of io.EOF because a GGUF
+// file can't end within its header.
+func (h *headerReader) fail(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+func (h *headerReader) bytes(n int) []byte {
+	if h.err != nil {
+		return nil
+	}
+	b := make([]byte, 0, minInt(n, 4096))
+	for len(b) < n && h.err == nil {
+		k := minInt(n-len(b), 4096)
+		b = append(b, make([]byte, k)...)
+		_, h.err = io.ReadFull(h.r, b[len(b)-k:])
+	}
+	h.n += int64(n)
+	return b
+}
+
+func (h *headerReader) u8() uint8 {
+	if b := h.bytes(1); h.err == nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (h *headerReader) u16() uint16 {
+	if b := h.bytes(2); h.err == nil {
+		return binary.LittleEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (h *headerReader) u32() uint32 {
+	if b := h.bytes(4); h.err == nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (h *headerReader) u64() uint64 {
+	if b := h.bytes(8); h.err == nil {
+		return binary.LittleEndian.Uint64(b)
+	}
+	return 0
+}
+
+// count reads a uint64 length or count, which must be at most maxLen.
+func (h *headerReader) count() int {
+	n := h.u64()
+	if n > maxLen && h.err == nil {
+		h.err = ErrFormat
+	}
+	return int(n)
+}
+
+func (h *headerReader) str() string {
+	return string(h.bytes(h.count()))
+}
+
+// value reads a metadata value of type typ.  depth limits nested arrays.
+func (h *headerReader) value(typ uint32, depth int) interface{} {
+	switch typ {
+	case 0:
+		return h.u8()
+	case 1:
+		return int8(h.u8())
+	case 2:
+		return h.u16()
+	case 3:
+		return int16(h.u16())
+	case 4:
+		return h.u32()
+	case 5:
+		return int32(h.u32())
+	case 6:
+		return math.Float32frombits(h.u32())
+	case 7:
+		return h.u8() != 0
+	case 8:
+		return h.str()
+	case 9:
+		elem, n := h.u32(), h.count()
+		if depth >= 8 && h.err == nil {
+			h.err = ErrFormat
+		}
+		a := []interface{}{}
+		for i := 0; i < n && h.err == nil; i++ {
+			a = append(a, h.value(elem, depth+1))
+		}
+		return a
+	case 10:
+		return h.u64()
+	case 11:
+		return int64(h.u64())
+	case 12:
+		return math.Float64frombits(h.u64())
+	}
+	h.err = ErrFormat
+	return nil
+}
@@ -0,0 +1,274 @@
+// Copyright 2019 Montgomery Edwards⁴⁴⁸ and Faye Amacker
+
+package gguf_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/x448/float16"
+	"github.com/x448/float16/gguf"
+)
+
+// builder builds a GGUF file.
+type builder struct{ bytes.Buffer }
+
+func (b *builder) u32(v uint32) *builder {
+	binary.Write(b, binary.LittleEndian, v)
+	return b
+}
+
+func (b *builder) u64(v uint64) *builder {
+	binary.Write(b, binary.LittleEndian, v)
+	return b
+}
+
+func (b *builder) str(s string) *builder {
+	b.u64(uint64(len(s)))
+	b.WriteString(s)
+	return b
+}
+
+// header starts a version 3 file.
+func header(tensors, kvs uint64) *builder {
+	b := &builder{}
+	b.WriteString("GGUF")
+	return b.u32(3).u64(tensors).u64(kvs)
+}
+
+// tensor appends a tensor info.
+func (b *builder) tensor(name string, typ gguf.Type, offset uint64, dims ...uint64) *builder {
+	b.str(name).u32(uint32(len(dims)))
+	for _, d := range dims {
+		b.u64(d)
+	}
+	return b.u32(uint32(typ)).u64(offset)
+}
+
+// data pads the file to a multiple of alignment and appends data.
+func (b *builder) data(alignment int, data ...byte) []byte {
+	for b.Len()%alignment != 0 {
+		b.WriteByte(0)
+	}
+	b.Write(data)
+	return b.Bytes()
+}
+
+func TestOpen(t *testing.T) {
+	b := header(4, 14)
+	b.str("u8").u32(0).WriteByte(200)
+	b.str("i8").u32(1).WriteByte(0xff)
+	b.str("u16").u32(2).Write([]byte{0x34, 0x12})
+	b.str("i16").u32(3).Write([]byte{0xfe, 0xff})
+	b.str("u32").u32(4).u32(7)
+	b.str("i32").u32(5).u32(0xfffffff9)
+	b.str("f32").u32(6).u32(math.Float32bits(1.5))
+	b.str("bool").u32(7).WriteByte(1)
+	b.str("general.name").u32(8).str("test")
+	b.str("arr").u32(9).u32(9).u64(2).u32(4).u64(1).u32(5).u32(4).u64(0)
+	b.str("u64").u32(10).u64(1 << 40)
+	b.str("i64").u32(11).u64(math.MaxUint64)
+	b.str("f64").u32(12).u64(math.Float64bits(-0.25))
+	b.str("general.alignment").u32(4).u32(64)
+	b.tensor("half", gguf.TypeF16, 0, 3, 2)
+	b.tensor("q8", gguf.TypeQ8_0, 64, 32)
+	b.tensor("q8k", gguf.TypeQ8_K, 128, 256)
+	b.tensor("iq", gguf.TypeIQ2_XXS, 448, 256)
+
+	half := []float16.Float16{0x3c00, 0x4000, 0x7c01, 0x8001, 0xfbff, 0}
+	q8 := make([]byte, 34)
+	binary.LittleEndian.PutUint16(q8, 0x3800) // 0.5
+	for i := range q8[2:] {
+		q8[2+i] = byte(i - 16)
+	}
+	data := make([]byte, 448)
+	copy(data, float16.Float16sToBytes(half))
+	copy(data[64:], q8)
+	file := b.data(64, data...)
+
+	f, err := gguf.Open(bytes.NewReader(file))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	want := map[string]interface{}{
+		"u8": uint8(200), "i8": int8(-1), "u16": uint16(0x1234), "i16": int16(-2),
+		"u32": uint32(7), "i32": int32(-7), "f32": float32(1.5), "bool": true,
+		"general.name": "test", "arr": []interface{}{[]interface{}{uint32(5)}, []interface{}{}},
+		"u64": uint64(1 << 40), "i64": int64(-1), "f64": -0.25, "general.alignment": uint32(64),
+	}
+	if f.Version != 3 || !reflect.DeepEqual(f.Metadata, want) {
+		t.Errorf("Open = version %d, metadata %v, wanted 3, %v", f.Version, f.Metadata, want)
+	}
+
+	var names []string
+	for _, ti := range f.Tensors() {
+		names = append(names, ti.Name)
+	}
+	if want := []string{"half", "iq", "q8", "q8k"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("Tensors() names = %v, wanted %v", names, want)
+	}
+	dataOffset := int64(len(file) - len(data))
+	for _, tc := range []struct {
+		name string
+		want gguf.TensorInfo
+	}{
+		{"half", gguf.TensorInfo{Name: "half", Dims: []uint64{3, 2}, Type: gguf.TypeF16, Offset: dataOffset, Size: 12}},
+		{"q8", gguf.TensorInfo{Name: "q8", Dims: []uint64{32}, Type: gguf.TypeQ8_0, Offset: dataOffset + 64, Size: 34}},
+		{"q8k", gguf.TensorInfo{Name: "q8k", Dims: []uint64{256}, Type: gguf.TypeQ8_K, Offset: dataOffset + 128, Size: 292}},
+		{"iq", gguf.TensorInfo{Name: "iq", Dims: []uint64{256}, Type: gguf.TypeIQ2_XXS, Offset: dataOffset + 448, Size: -1}},
+	} {
+		if got, ok := f.Tensor(tc.name); !ok || !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("Tensor(%q) = %+v, %t, wanted %+v", tc.name, got, ok, tc.want)
+		}
+	}
+	if ti, _ := f.Tensor("half"); ti.Len() != 6 {
+		t.Errorf("Len() = %d, wanted 6", ti.Len())
+	}
+
+	// F16 values are returned unchanged, including NaN payloads.
+	if got, err := f.Float16s("half"); err != nil || !reflect.DeepEqual(got, half) {
+		t.Errorf("Float16s(half) = %v, %v, wanted %v", got, err, half)
+	}
+	f32s, err := f.Float32s("q8")
+	if err != nil || len(f32s) != 32 {
+		t.Fatalf("Float32s(q8) = %v, %v", f32s, err)
+	}
+	f16s, err := f.Float16s("q8")
+	if err != nil || len(f16s) != 32 {
+		t.Fatalf("Float16s(q8) = %v, %v", f16s, err)
+	}
+	for i := range f32s {
+		if want := float32(i-16) * 0.5; f32s[i] != want || f16s[i] != float16.Fromfloat32(want) {
+			t.Errorf("q8[%d] = %v, %v, wanted %v", i, f32s[i], f16s[i], want)
+		}
+	}
+
+	if raw, err := f.Bytes("q8k"); err != nil || len(raw) != 292 {
+		t.Errorf("Bytes(q8k) = %d bytes, %v, wanted 292, nil", len(raw), err)
+	}
+	for name, wantErr := range map[string]error{"q8k": gguf.ErrType, "iq": gguf.ErrType, "missing": gguf.ErrNotFound} {
+		if _, err := f.Float16s(name); err != wantErr {
+			t.Errorf("Float16s(%q) = %v, wanted %v", name, err, wantErr)
+		}
+		if _, err := f.Float32s(name); err != wantErr {
+			t.Errorf("Float32s(%q) = %v, wanted %v", name, err, wantErr)
+		}
+	}
+	for name, wantErr := range map[string]error{"iq": gguf.ErrType, "missing": gguf.ErrNotFound} {
+		if _, err := f.Bytes(name); err != wantErr {
+			t.Errorf("Bytes(%q) = %v, wanted %v", name, err, wantErr)
+		}
+	}
+}
+
+func TestOpenDefaultAlignment(t *testing.T) {
+	b := header(1, 0)
+	file := b.tensor("x", gguf.TypeF32, 0, 1).data(32, 0, 0, 0x80, 0x3f)
+	f, err := gguf.Open(bytes.NewReader(file))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if got, err := f.Float32s("x"); err != nil || len(got) != 1 || got[0] != 1 {
+		t.Errorf("Float32s(x) = %v, %v, wanted [1], nil", got, err)
+	}
+}
+
+func TestOpenLargeTensor(t *testing.T) {
+	if math.MaxInt == math.MaxInt32 {
+		t.Skip("tensor is larger than an int on this platform")
+	}
+	// A token embedding with 128256 tokens of 16384 values each.
+	file := header(1, 0).tensor("token_embd.weight", gguf.TypeF16, 0, 16384, 128256).data(32)
+	f, err := gguf.Open(bytes.NewReader(file))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	const want = int64(2 * 16384 * 128256)
+	if got, _ := f.Tensor("token_embd.weight"); got.Size != want {
+		t.Errorf("Size = %d, wanted %d", got.Size, want)
+	}
+}
+
+// errReaderAt returns errRead instead of io.EOF for reads past its data.
+type errReaderAt []byte
+
+var errRead = errors.New("read failed")
+
+func (r errReaderAt) ReadAt(b []byte, off int64) (int, error) {
+	if off >= int64(len(r)) {
+		return 0, errRead
+	}
+	if n := copy(b, r[off:]); n < len(b) {
+		return n, errRead
+	}
+	return len(b), nil
+}
+
+func TestOpenErrors(t *testing.T) {
+	valid := header(1, 2)
+	valid.str("k").u32(8).str("v")
+	valid.str("k16").u32(2).Write([]byte{0x34, 0x12})
+	headerLen := valid.tensor("x", gguf.TypeQ8_0, 0, 32).Len()
+	file := valid.data(32, make([]byte, 34)...)
+
+	// Every truncated header fails.
+	for n := 0; n < headerLen; n++ {
+		if _, err := gguf.Open(bytes.NewReader(file[:n])); err != io.ErrUnexpectedEOF {
+			t.Fatalf("Open(%d of %d bytes) = %v, wanted %v", n, headerLen, err, io.ErrUnexpectedEOF)
+		}
+	}
+	if _, err := gguf.Open(errReaderAt(file[:10])); err != errRead {
+		t.Errorf("Open(errReaderAt) = %v, wanted %v", err, errRead)
+	}
+
+	// Truncated tensor data fails when it's read.
+	for _, tc := range []struct {
+		r    io.ReaderAt
+		want error
+	}{
+		{bytes.NewReader(file[:len(file)-1]), io.ErrUnexpectedEOF},
+		{errReaderAt(file[:len(file)-1]), errRead},
+	} {
+		f, err := gguf.Open(tc.r)
+		if err != nil {
+			t.Fatalf("Open: %v", err)
+		}
+		if _, err := f.Float32s("x"); err != tc.want {
+			t.Errorf("Float32s with truncated data = %v, wanted %v", err, tc.want)
+		}
+	}
+
+	deep := header(0, 1).str("a").u32(9)
+	for i := 0; i < 10; i++ {
+		deep.u32(9).u64(1) // arrays of arrays
+	}
+	for _, tc := range []struct {
+		b    []byte
+		want error
+	}{
+		{[]byte("GGUX\x03\x00\x00\x00"), gguf.ErrFormat},
+		{append([]byte("GGUF"), 1, 0, 0, 0), gguf.ErrVersion},
+		{header(1<<31, 0).Bytes(), gguf.ErrFormat},
+		{header(0, 1).str("a").u32(13).Bytes(), gguf.ErrFormat},
+		{header(0, 1).str("a").u32(8).u64(1 << 40).Bytes(), gguf.ErrFormat},
+		{deep.Bytes(), gguf.ErrFormat},
+		{header(0, 1).str("general.alignment").u32(10).u64(32).Bytes(), gguf.ErrFormat},
+		{header(0, 1).str("general.alignment").u32(4).u32(0).Bytes(), gguf.ErrFormat},
+		{header(0, 1).str("general.alignment").u32(4).u32(48).Bytes(), gguf.ErrFormat},
+		{header(1, 0).tensor("x", gguf.TypeF16, 0, 1, 1, 1, 1, 1).Bytes(), gguf.ErrFormat},
+		{header(1, 0).tensor("x", gguf.TypeQ8_0, 0, 31).Bytes(), gguf.ErrFormat},
+		{header(1, 0).tensor("x", gguf.TypeF16, 0, 1<<32, 1<<32).Bytes(), gguf.ErrFormat},
+		{header(1, 0).tensor("x", gguf.TypeF32, 0, 1<<62).Bytes(), gguf.ErrFormat},
+		{header(1, 0).tensor("x", gguf.TypeF16, 2, 1).Bytes(), gguf.ErrFormat},
+		{header(1, 0).tensor("x", gguf.TypeF16, 1<<63, 1).Bytes(), gguf.ErrFormat},
+	} {
+		if _, err := gguf.Open(bytes.NewReader(tc.b)); err != tc.want {
+			t.Errorf("Open(%q) = %v, wanted %v", tc.b, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+// Copyright 2019 Montgomery Edwards⁴⁴⁸ and Faye Amacker
+
+package float16
+
+import (
+	"image"
+	"image/color"
+)
+
+// RGBA64F16Color is a color with Float16 red, green, blue and alpha values.
+// Like color.RGBA64, the colors are premultiplied by alpha, but values aren't
+// limited to [0, 1], so they can hold high dynamic range colors.
+type RGBA64F16Color struct {
+	R, G, B, A Float16
+}
+
+// RGBA implements color.Color.  It converts c like c.ToRGBA64(nil).
+func (c RGBA64F16Color) RGBA() (r, g, b, a uint32) {
+	c64 := c.ToRGBA64(nil)
+	return uint32(c64.R), uint32(c64.G), uint32(c64.B), uint32(c64.A)
+}
+
+// A ToneMap maps premultiplied high dynamic range color values and alpha to
+// the range [0, 1] for conversion to 16-bit colors.  Values it returns
+// outside that range are still clamped.
+type ToneMap func(r, g, b, a float32) (float32, float32, float32, float32)
+
+// ToRGBA64 converts c to a 16-bit color by applying tm, if it isn't nil, and
+// then clamping.  Clamping maps NaN to 0, limits alpha to [0, 1], and limits
+// red, green and blue to [0, alpha], as premultiplied colors require.
+func (c RGBA64F16Color) ToRGBA64(tm ToneMap) color.RGBA64 {
+	r, g, b, a := c.R.Float32(), c.G.Float32(), c.B.Float32(), c.A.Float32()
+	if tm != nil {
+		r, g, b, a = tm(r, g, b, a)
+	}
+	a = clamp(a, 1)
+	return color.RGBA64{R: unit16(clamp(r, a)), G: unit16(clamp(g, a)), B: unit16(clamp(b, a)), A: unit16(a)}
+}
+
+// clamp limits v to [0, hi], mapping NaN to 0.
+func clamp(v, hi float32) float32 {
+	if v > hi {
+		return hi
+	}
+	if v > 0 {
+		return v
+	}
+	return 0
+}
+
+// unit16 returns v in [0, 1] scaled to [0, 0xffff] and rounded.
+func unit16(v float32) uint16 {
+	return uint16(v*0xffff + 0.5)
+}
+
+// RGBA64F16Model converts any color.Color to RGBA64F16Color.  Colors other
+// than RGBA64F16Color are converted from their 16-bit RGBA values, so they
+// stay in [0, 1].
+var RGBA64F16Model color.Model = color.ModelFunc(rgba64F16Model)
+
+func rgba64F16Model(c color.Color) color.Color {
+	if _, ok := c.(RGBA64F16Color); ok {
+		return c
+	}
+	r, g, b, a := c.RGBA()
+	return RGBA64F16Color{fromUnit16(r), fromUnit16(g), fromUnit16(b), fromUnit16(a)}
+}
+
+// fromUnit16 returns v in [0, 0xffff] scaled to [0, 1].
+func fromUnit16(v uint32) Float16 {
+	return Fromfloat32(float32(v) / 0xffff)
+}
+
+// RGBA64F16 is an in-memory image whose At method returns RGBA64F16Color
+// values.  It implements draw.Image, so image/draw can draw into it, and
+// image.RGBA64Image, whose RGBA64At converts pixels like ToRGBA64(nil).
+type RGBA64F16 struct {
+	// Pix holds the image's pixels in R, G, B, A order.  The pixel at (x, y)
+	// starts at Pix[(y-Rect.Min.Y)*Stride + (x-Rect.Min.X)*4].
+	Pix []Float16
+
+	// Stride is the Pix stride in values between vertically adjacent pixels.
+	Stride int
+
+	// Rect is the image's bounds.
+	Rect image.Rectangle
+}
+
+// NewRGBA64F16 returns a new RGBA64F16 image with the given bounds.  All its
+// pixels are transparent black.
+func NewRGBA64F16(r image.Rectangle) *RGBA64F16 {
+	w, h := r.Dx(), r.Dy()
+	return &RGBA64F16{Pix: make([]Float16, 4*w*h), Stride: 4 * w, Rect: r}
+}
+
+// ColorModel returns RGBA64F16Model.
+func (p *RGBA64F16) ColorModel() color.Model { return RGBA64F16Model }
+
+// Bounds returns the image's bounds.
+func (p *RGBA64F16) Bounds() image.Rectangle { return p.Rect }
+
+// At returns the color of the pixel at (x, y) as an RGBA64F16Color.
+func (p *RGBA64F16) At(x, y int) color.Color {
+	return p.RGBA64F16At(x, y)
+}
+
+// RGBA64At returns the color of the pixel at (x, y) converted like ToRGBA64(nil).
+func (p *RGBA64F16) RGBA64At(x, y int) color.RGBA64 {
+	return p.RGBA64F16At(x, y).ToRGBA64(nil)
+}
+
+// RGBA64F16At returns the color of the pixel at (x, y), or transparent black
+// if (x, y) is outside the image.
+func (p *RGBA64F16) RGBA64F16At(x, y int) RGBA64F16Color {
+	if !(image.Point{x, y}.In(p.Rect)) {
+		return RGBA64F16Color{}
+	}
+	s := p.Pix[p.PixOffset(x, y):]
+	return RGBA64F16Color{s[0], s[1], s[2], s[3]}
+}
+
+// PixOffset returns the index of the first value of the pixel at (x, y) in Pix.
+func (p *RGBA64F16) PixOffset(x, y int) int {
+	return (y-p.Rect.Min.Y)*p.Stride + (x-p.Rect.Min.X)*4
+}
+
+// Set sets the pixel at (x, y) to c converted by RGBA64F16Model.
+func (p *RGBA64F16) Set(x, y int, c color.Color) {
+	p.SetRGBA64F16(x, y, RGBA64F16Model.Convert(c).(RGBA64F16Color))
+}
+
+// SetRGBA64 sets the pixel at (x, y) to c scaled to [0, 1].
+func (p *RGBA64F16) SetRGBA64(x, y int, c color.RGBA64) {
+	p.SetRGBA64F16(x, y, RGBA64F16Color{
+		fromUnit16(uint32(c.R)), fromUnit16(uint32(c.G)), fromUnit16(uint32(c.B)), fromUnit16(uint32(c.A)),
+	})
+}
+
+// SetRGBA64F16 sets the pixel at (x, y) to c if (x, y) is inside the image.
+func (p *RGBA64F16) SetRGBA64F16(x, y int, c RGBA64F16Color) {
+	if !(image.Point{x, y}.In(p.Rect)) {
+		return
+	}
+	s := p.Pix[p.PixOffset(x, y):]
+	s[0], s[1], s[2], s[3] = c.R, c.G, c.B, c.A
+}
+
+// SubImage returns an image representing the portion of p visible through r.
+// The returned image shares pixels with p.
+func (p *RGBA64F16) SubImage(r image.Rectangle) image.Image {
+	r = r.Intersect(p.Rect)
+	if r.Empty() {
+		return &RGBA64F16{}
+	}
+	i := p.PixOffset(r.Min.X, r.Min.Y)
+	return &RGBA64F16{Pix: p.Pix[i:], Stride: p.Stride, Rect: r}
+}
+
+// Opaque reports whether every pixel has an alpha of at least 1.
+func (p *RGBA64F16) Opaque() bool {
+	if p.Rect.Empty() {
+		return true
+	}
+	for y := p.Rect.Min.Y; y < p.Rect.Max.Y; y++ {
+		row := p.Pix[p.PixOffset(p.Rect.Min.X, y):][:4*p.Rect.Dx()]
+		for i := 3; i < len(row); i += 4 {
+			if !(row[i].Float32() >= 1) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// ToRGBA64 returns a copy of p converted to 16-bit colors like
+// RGBA64F16Color.ToRGBA64 with tm, such as for image/png.
+func (p *RGBA64F16) ToRGBA64(tm ToneMap) *image.RGBA64 {
+	m := image.NewRGBA64(p.Rect)
+	for y := p.Rect.Min.Y; y < p.Rect.Max.Y; y++ {
+		for x := p.Rect.Min.X; x < p.Rect.Max.X; x++ {
+			m.SetRGBA64(x, y, p.RGBA64F16At(x, y).ToRGBA64(tm))
+		}
+	}
+	return m
+}
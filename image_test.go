@@ -0,0 +1,157 @@
+// Copyright 2019 Montgomery Edwards⁴⁴⁸ and Faye Amacker
+
+package float16_test
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/x448/float16"
+)
+
+var (
+	_ draw.Image                 = (*float16.RGBA64F16)(nil)
+	_ draw.RGBA64Image           = (*float16.RGBA64F16)(nil)
+	_ color.Color                = float16.RGBA64F16Color{}
+	_ image.RGBA64Image          = (*float16.RGBA64F16)(nil)
+	_ float16.ToneMap            = reinhard
+	_ interface{ Opaque() bool } = (*float16.RGBA64F16)(nil)
+)
+
+// reinhard is the Reinhard tone map r/(1+r) applied to each color.
+func reinhard(r, g, b, a float32) (float32, float32, float32, float32) {
+	return r / (1 + r), g / (1 + g), b / (1 + b), a
+}
+
+func TestRGBA64F16ColorToRGBA64(t *testing.T) {
+	nan := float16.NaN()
+	for _, tc := range []struct {
+		c    float16.RGBA64F16Color
+		tm   float16.ToneMap
+		want color.RGBA64
+	}{
+		{float16.RGBA64F16Color{0x3c00, 0x3800, 0, 0x3c00}, nil, color.RGBA64{0xffff, 0x8000, 0, 0xffff}},
+		{float16.RGBA64F16Color{0x4000, 0xbc00, nan, 0x3c00}, nil, color.RGBA64{0xffff, 0, 0, 0xffff}},
+		{float16.RGBA64F16Color{0x3c00, 0x3400, 0, 0x3800}, nil, color.RGBA64{0x8000, 0x4000, 0, 0x8000}},
+		{float16.RGBA64F16Color{0x3c00, 0, 0, 0x4000}, nil, color.RGBA64{0xffff, 0, 0, 0xffff}},
+		{float16.RGBA64F16Color{0x3c00, 0, 0, nan}, nil, color.RGBA64{}},
+		{float16.RGBA64F16Color{0x4000, 0x3c00, 0, 0x3c00}, reinhard, color.RGBA64{0xaaaa, 0x8000, 0, 0xffff}},
+		{float16.RGBA64F16Color{0x7c00, 0, 0, 0x3c00}, reinhard, color.RGBA64{0, 0, 0, 0xffff}},
+	} {
+		if got := tc.c.ToRGBA64(tc.tm); got != tc.want {
+			t.Errorf("%v.ToRGBA64 = %v, wanted %v", tc.c, got, tc.want)
+		}
+		if tc.tm == nil {
+			r, g, b, a := tc.c.RGBA()
+			if got := (color.RGBA64{uint16(r), uint16(g), uint16(b), uint16(a)}); got != tc.want {
+				t.Errorf("%v.RGBA = %v, wanted %v", tc.c, got, tc.want)
+			}
+		}
+	}
+}
+
+func TestRGBA64F16Model(t *testing.T) {
+	c := float16.RGBA64F16Color{0x4000, 0x3c00, 0, 0x3c00}
+	if got := float16.RGBA64F16Model.Convert(c); got != c {
+		t.Errorf("Convert(%v) = %v, wanted it unchanged", c, got)
+	}
+	for _, tc := range []struct {
+		c    color.Color
+		want float16.RGBA64F16Color
+	}{
+		{color.RGBA64{0xffff, 0x8000, 0, 0xffff}, float16.RGBA64F16Color{0x3c00, 0x3800, 0, 0x3c00}},
+		{color.NRGBA{0xff, 0, 0, 0x80}, float16.RGBA64F16Color{0x3804, 0, 0, 0x3804}},
+		{color.Gray{0x33}, float16.RGBA64F16Color{0x3266, 0x3266, 0x3266, 0x3c00}},
+	} {
+		if got := float16.RGBA64F16Model.Convert(tc.c); got != tc.want {
+			t.Errorf("Convert(%v) = %v, wanted %v", tc.c, got, tc.want)
+		}
+	}
+}
+
+func TestRGBA64F16(t *testing.T) {
+	m := float16.NewRGBA64F16(image.Rect(-1, -1, 3, 2))
+	if m.Bounds() != image.Rect(-1, -1, 3, 2) || len(m.Pix) != 48 || m.Stride != 16 || m.ColorModel() != float16.RGBA64F16Model {
+		t.Fatalf("NewRGBA64F16 = %+v", m)
+	}
+	hdr := float16.RGBA64F16Color{0x4800, 0x3c00, 0x3400, 0x3c00}
+	m.SetRGBA64F16(2, 1, hdr)
+	m.SetRGBA64F16(3, 1, hdr)
+	if i := m.PixOffset(2, 1); m.Pix[i] != 0x4800 || i != 44 {
+		t.Errorf("PixOffset(2, 1) = %d", i)
+	}
+	if got := m.At(2, 1); got != hdr {
+		t.Errorf("At(2, 1) = %v, wanted %v", got, hdr)
+	}
+	if got := m.RGBA64At(2, 1); got != (color.RGBA64{0xffff, 0xffff, 0x4000, 0xffff}) {
+		t.Errorf("RGBA64At(2, 1) = %v", got)
+	}
+	if got := m.At(3, 1); got != (float16.RGBA64F16Color{}) {
+		t.Errorf("At(3, 1) = %v, wanted transparent", got)
+	}
+
+	m.Set(0, 0, color.Gray{0xff})
+	m.SetRGBA64(1, 0, color.RGBA64{0, 0x8000, 0, 0x8000})
+	if got := m.RGBA64F16At(0, 0); got != (float16.RGBA64F16Color{0x3c00, 0x3c00, 0x3c00, 0x3c00}) {
+		t.Errorf("RGBA64F16At(0, 0) = %v", got)
+	}
+	if got := m.RGBA64F16At(1, 0); got != (float16.RGBA64F16Color{0, 0x3800, 0, 0x3800}) {
+		t.Errorf("RGBA64F16At(1, 0) = %v", got)
+	}
+
+	// SubImage shares pixels.
+	sub := m.SubImage(image.Rect(1, 0, 10, 10)).(*float16.RGBA64F16)
+	if sub.Bounds() != image.Rect(1, 0, 3, 2) || sub.At(2, 1) != hdr {
+		t.Errorf("SubImage = %v with %v at (2, 1)", sub.Bounds(), sub.At(2, 1))
+	}
+	sub.SetRGBA64F16(1, 1, hdr)
+	if m.At(1, 1) != hdr {
+		t.Errorf("SubImage doesn't share pixels")
+	}
+	if empty := m.SubImage(image.Rect(5, 5, 6, 6)); !empty.Bounds().Empty() {
+		t.Errorf("SubImage outside = %v, wanted empty", empty.Bounds())
+	}
+
+	if m.Opaque() || !sub.SubImage(image.Rect(1, 1, 3, 2)).(*float16.RGBA64F16).Opaque() || !m.SubImage(image.Rectangle{}).(*float16.RGBA64F16).Opaque() {
+		t.Errorf("Opaque is wrong")
+	}
+
+	rgba := m.ToRGBA64(reinhard)
+	if rgba.Bounds() != m.Bounds() {
+		t.Fatalf("ToRGBA64 bounds = %v, wanted %v", rgba.Bounds(), m.Bounds())
+	}
+	if got, want := rgba.RGBA64At(2, 1), (color.RGBA64{0xe38d, 0x8000, 0x3333, 0xffff}); got != want {
+		t.Errorf("ToRGBA64(reinhard) at (2, 1) = %v, wanted %v", got, want)
+	}
+}
+
+func TestRGBA64F16Draw(t *testing.T) {
+	// Drawing an 8-bit image into an RGBA64F16 and back keeps every value
+	// within 1, because Float16 has 11 bits of precision.
+	src := image.NewNRGBA(image.Rect(0, 0, 256, 4))
+	for x := 0; x < 256; x++ {
+		for y := 0; y < 4; y++ {
+			src.SetNRGBA(x, y, color.NRGBA{uint8(x), uint8(255 - x), uint8(x * 7), uint8(255 - y*85)})
+		}
+	}
+	want := image.NewRGBA(src.Rect)
+	draw.Draw(want, want.Rect, src, image.Point{}, draw.Src)
+
+	m := float16.NewRGBA64F16(src.Rect)
+	draw.Draw(m, m.Rect, src, image.Point{}, draw.Src)
+	got := image.NewRGBA(src.Rect)
+	draw.Draw(got, got.Rect, m, image.Point{}, draw.Src)
+	for i := range got.Pix {
+		if d := int(got.Pix[i]) - int(want.Pix[i]); d < -1 || d > 1 {
+			t.Fatalf("Pix[%d] = %d, wanted %d", i, got.Pix[i], want.Pix[i])
+		}
+	}
+
+	// Drawing over an RGBA64F16 uses its colors.
+	draw.Draw(m, m.Rect, image.NewUniform(color.RGBA64{0, 0, 0, 0x8000}), image.Point{}, draw.Over)
+	if c := m.RGBA64F16At(255, 0); c.A != 0x3c00 || c.R != 0x3800 {
+		t.Errorf("draw.Over = %v, wanted R 0.5, A 1", c)
+	}
+}
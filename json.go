@@ -0,0 +1,278 @@
+// Copyright 2019 Montgomery Edwards⁴⁴⁸ and Faye Amacker
+
+package float16
+
+import (
+	"math"
+)
+
+// NonFinite specifies how NaN and infinity are encoded as JSON, which has
+// no numbers for them.
+type NonFinite int
+
+const (
+
+	// NonFiniteError makes encoding NaN or infinity return ErrJSONNonFinite.
+	// This is the default and matches encoding/json for float32 and float64.
+	NonFiniteError NonFinite = iota
+
+	// NonFiniteNull encodes NaN and infinity as JSON null.
+	NonFiniteNull
+
+	// NonFiniteString encodes NaN and infinity as the JSON strings
+	// "NaN", "Infinity" and "-Infinity".
+	NonFiniteString
+)
+
+// ErrJSONNonFinite indicates NaN or infinity was encoded with NonFiniteError.
+const ErrJSONNonFinite = float16Error("float16: NaN and infinity can't be encoded as JSON numbers")
+
+// ErrJSONSyntax indicates invalid JSON was received.
+const ErrJSONSyntax = float16Error("float16: invalid JSON, expected number, null, \"NaN\", \"Infinity\" or \"-Infinity\"")
+
+// MarshalJSON satisfies the json.Marshaler interface.  Finite values are encoded
+// as JSON numbers using the shortest decimal that round-trips through binary16.
+// NaN and infinity return ErrJSONNonFinite like encoding/json does for float32
+// and float64; use JSONNull, JSONString or MarshalJSONSliceWith to encode them.
+func (f Float16) MarshalJSON() ([]byte, error) {
+	return f.appendJSON(make([]byte, 0, 16), NonFiniteError)
+}
+
+// UnmarshalJSON satisfies the json.Unmarshaler interface.  It accepts JSON numbers,
+// which are rounded to nearest with ties to even, and the JSON strings "NaN",
+// "Infinity" and "-Infinity".  Following encoding/json convention, null
+// leaves f unchanged.
+func (f *Float16) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	v, err := parseJSON(data)
+	if err != nil {
+		return err
+	}
+	*f = v
+	return nil
+}
+
+// JSONNull is a Float16 that encodes NaN and infinity as JSON null, so
+// encoding/json can use NonFiniteNull for struct fields, slices and maps.
+type JSONNull Float16
+
+// MarshalJSON satisfies the json.Marshaler interface.  Finite values are encoded
+// like Float16.MarshalJSON and NaN and infinity are encoded as null.
+func (f JSONNull) MarshalJSON() ([]byte, error) {
+	return Float16(f).appendJSON(make([]byte, 0, 16), NonFiniteNull)
+}
+
+// UnmarshalJSON satisfies the json.Unmarshaler interface.  It decodes like
+// Float16.UnmarshalJSON, except null becomes NaN so values encoded by
+// MarshalJSON round-trip.
+func (f *JSONNull) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*f = JSONNull(NaN())
+		return nil
+	}
+	return (*Float16)(f).UnmarshalJSON(data)
+}
+
+// JSONString is a Float16 that encodes NaN and infinity as the JSON strings
+// "NaN", "Infinity" and "-Infinity", so encoding/json can use NonFiniteString
+// for struct fields, slices and maps.
+type JSONString Float16
+
+// MarshalJSON satisfies the json.Marshaler interface.  Finite values are encoded
+// like Float16.MarshalJSON and NaN and infinity are encoded as strings.
+func (f JSONString) MarshalJSON() ([]byte, error) {
+	return Float16(f).appendJSON(make([]byte, 0, 16), NonFiniteString)
+}
+
+// UnmarshalJSON satisfies the json.Unmarshaler interface in the same way as
+// Float16.UnmarshalJSON.
+func (f *JSONString) UnmarshalJSON(data []byte) error {
+	return (*Float16)(f).UnmarshalJSON(data)
+}
+
+// MarshalJSONSlice returns src encoded as a JSON array.  Elements are encoded
+// in the same way as MarshalJSON and a nil slice is encoded as null.
+func MarshalJSONSlice(src []Float16) ([]byte, error) {
+	return MarshalJSONSliceWith(src, NonFiniteError)
+}
+
+// MarshalJSONSliceWith is like MarshalJSONSlice, but encodes NaN and infinity
+// according to mode.
+func MarshalJSONSliceWith(src []Float16, mode NonFinite) ([]byte, error) {
+	if src == nil {
+		return []byte("null"), nil
+	}
+	b := make([]byte, 0, 2+8*len(src))
+	b = append(b, '[')
+	for i, f := range src {
+		if i > 0 {
+			b = append(b, ',')
+		}
+		var err error
+		if b, err = f.appendJSON(b, mode); err != nil {
+			return nil, err
+		}
+	}
+	return append(b, ']'), nil
+}
+
+// UnmarshalJSONSlice decodes a JSON array of numbers into a new []Float16.
+// Elements are decoded in the same way as UnmarshalJSON, except null
+// elements become NaN so arrays encoded with NonFiniteNull keep their length.
+// A JSON null returns a nil slice.
+func UnmarshalJSONSlice(data []byte) ([]Float16, error) {
+	i := skipJSONSpace(data, 0)
+	if i+4 <= len(data) && string(data[i:i+4]) == "null" && skipJSONSpace(data, i+4) == len(data) {
+		return nil, nil
+	}
+	if i == len(data) || data[i] != '[' {
+		return nil, ErrJSONSyntax
+	}
+
+	dst := make([]Float16, 0, len(data)/8)
+	i = skipJSONSpace(data, i+1)
+	if i < len(data) && data[i] == ']' {
+		return dst, checkJSONEnd(data, i+1)
+	}
+	for {
+		start := i
+		for i < len(data) && data[i] != ',' && data[i] != ']' && !isJSONSpace(data[i]) {
+			i++
+		}
+		token := data[start:i]
+		if string(token) == "null" {
+			dst = append(dst, NaN())
+		} else {
+			f, err := parseJSON(token)
+			if err != nil {
+				return nil, err
+			}
+			dst = append(dst, f)
+		}
+
+		i = skipJSONSpace(data, i)
+		if i == len(data) {
+			return nil, ErrJSONSyntax
+		}
+		if data[i] == ']' {
+			return dst, checkJSONEnd(data, i+1)
+		}
+		if data[i] != ',' {
+			return nil, ErrJSONSyntax
+		}
+		i = skipJSONSpace(data, i+1)
+	}
+}
+
+// appendJSON appends the JSON encoding of f to dst.  Finite values use the
+// same formats as encoding/json uses for float32 and float64.
+func (f Float16) appendJSON(dst []byte, mode NonFinite) ([]byte, error) {
+	if !f.IsFinite() {
+		switch mode {
+		case NonFiniteNull:
+			return append(dst, "null"...), nil
+		case NonFiniteString:
+			switch {
+			case f.IsNaN():
+				return append(dst, `"NaN"`...), nil
+			case f.Signbit():
+				return append(dst, `"-Infinity"`...), nil
+			}
+			return append(dst, `"Infinity"`...), nil
+		}
+		return dst, ErrJSONNonFinite
+	}
+
+	if abs := math.Abs(f.Float64()); abs == 0 || abs >= 1e-6 {
+		return f.AppendFormat(dst, 'f', -1), nil
+	}
+
+	// Like encoding/json, clean up e-07 to e-7.
+	dst = f.AppendFormat(dst, 'e', -1)
+	if n := len(dst); n >= 4 && dst[n-4] == 'e' && dst[n-3] == '-' && dst[n-2] == '0' {
+		dst[n-2] = dst[n-1]
+		dst = dst[:n-1]
+	}
+	return dst, nil
+}
+
+// parseJSON decodes a JSON number or one of the strings "NaN", "Infinity"
+// and "-Infinity".
+func parseJSON(data []byte) (Float16, error) {
+	switch string(data) {
+	case `"NaN"`:
+		return NaN(), nil
+	case `"Infinity"`:
+		return Inf(1), nil
+	case `"-Infinity"`:
+		return Inf(-1), nil
+	}
+	if !isJSONNumber(data) {
+		return 0, ErrJSONSyntax
+	}
+	return parse(string(data))
+}
+
+// isJSONNumber reports whether b is a valid JSON number (RFC 8259 section 6).
+// strconv.ParseFloat alone accepts more, such as "Inf", "0x1p-2" and "+1".
+func isJSONNumber(b []byte) bool {
+	i := 0
+	if i < len(b) && b[i] == '-' {
+		i++
+	}
+	switch {
+	case i < len(b) && b[i] == '0':
+		i++
+	case i < len(b) && '1' <= b[i] && b[i] <= '9':
+		i = skipDigits(b, i)
+	default:
+		return false
+	}
+	if i < len(b) && b[i] == '.' {
+		j := skipDigits(b, i+1)
+		if j == i+1 {
+			return false
+		}
+		i = j
+	}
+	if i < len(b) && (b[i] == 'e' || b[i] == 'E') {
+		i++
+		if i < len(b) && (b[i] == '+' || b[i] == '-') {
+			i++
+		}
+		j := skipDigits(b, i)
+		if j == i {
+			return false
+		}
+		i = j
+	}
+	return i == len(b)
+}
+
+func skipDigits(b []byte, i int) int {
+	for i < len(b) && '0' <= b[i] && b[i] <= '9' {
+		i++
+	}
+	return i
+}
+
+func isJSONSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func skipJSONSpace(b []byte, i int) int {
+	for i < len(b) && isJSONSpace(b[i]) {
+		i++
+	}
+	return i
+}
+
+// checkJSONEnd returns ErrJSONSyntax if anything other than whitespace follows b[i-1].
+func checkJSONEnd(b []byte, i int) error {
+	if skipJSONSpace(b, i) != len(b) {
+		return ErrJSONSyntax
+	}
+	return nil
+}
@@ -0,0 +1,254 @@
+// Copyright 2019 Montgomery Edwards⁴⁴⁸ and Faye Amacker
+
+package float16_test
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/x448/float16"
+)
+
+// Test MarshalJSON and UnmarshalJSON round-trip all finite values, and that
+// encoding/json agrees on the numbers produced.
+func TestJSONRoundTrip(t *testing.T) {
+	for i := 0; i < 0x10000; i++ {
+		f16 := float16.Frombits(uint16(i))
+		if !f16.IsFinite() {
+			continue
+		}
+		b, err := json.Marshal(f16)
+		if err != nil {
+			t.Fatalf("0x%04x: json.Marshal returned error %v", i, err)
+		}
+
+		var got float16.Float16
+		if err := json.Unmarshal(b, &got); err != nil {
+			t.Fatalf("0x%04x: json.Unmarshal(%s) returned error %v", i, b, err)
+		}
+		if got != f16 {
+			t.Errorf("0x%04x: json.Unmarshal(%s) returned 0x%04x", i, b, got.Bits())
+		}
+
+		var f64 float64
+		if err := json.Unmarshal(b, &f64); err != nil {
+			t.Fatalf("0x%04x: json.Unmarshal(%s) into float64 returned error %v", i, b, err)
+		}
+	}
+}
+
+func TestMarshalJSON(t *testing.T) {
+	testCases := []struct {
+		in   float16.Float16
+		mode float16.NonFinite
+		want string
+		err  error
+	}{
+		{float16.Fromfloat32(0.1), float16.NonFiniteError, "0.1", nil},
+		{float16.Frombits(0x3c00), float16.NonFiniteError, "1", nil},
+		{float16.Frombits(0x7bff), float16.NonFiniteError, "65500", nil},
+		{float16.Frombits(0x8000), float16.NonFiniteError, "-0", nil},
+		{float16.Fromfloat32(1e-5), float16.NonFiniteError, "0.00001", nil},
+		{float16.SmallestNonzero, float16.NonFiniteError, "6e-8", nil},
+		{float16.Frombits(0x8010), float16.NonFiniteError, "-9.5e-7", nil},
+		{float16.NaN(), float16.NonFiniteError, "", float16.ErrJSONNonFinite},
+		{float16.Inf(1), float16.NonFiniteError, "", float16.ErrJSONNonFinite},
+		{float16.NaN(), float16.NonFiniteNull, "null", nil},
+		{float16.Inf(-1), float16.NonFiniteNull, "null", nil},
+		{float16.NaN(), float16.NonFiniteString, `"NaN"`, nil},
+		{float16.Inf(1), float16.NonFiniteString, `"Infinity"`, nil},
+		{float16.Inf(-1), float16.NonFiniteString, `"-Infinity"`, nil},
+	}
+	for _, tc := range testCases {
+		var got []byte
+		var err error
+		switch tc.mode {
+		case float16.NonFiniteNull:
+			got, err = json.Marshal(float16.JSONNull(tc.in))
+		case float16.NonFiniteString:
+			got, err = json.Marshal(float16.JSONString(tc.in))
+		default:
+			got, err = tc.in.MarshalJSON()
+		}
+		if err != tc.err || string(got) != tc.want {
+			t.Errorf("%#v with mode %d marshaled to %q, %v, wanted %q, %v", tc.in, tc.mode, got, err, tc.want, tc.err)
+		}
+	}
+
+	_, err := json.Marshal(struct{ X float16.Float16 }{float16.NaN()})
+	if !errors.Is(err, float16.ErrJSONNonFinite) {
+		t.Errorf("json.Marshal of NaN field returned error %v, wanted ErrJSONNonFinite", err)
+	}
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	testCases := []struct {
+		in   string
+		want uint16
+	}{
+		{"0.1", 0x2e66},
+		{"-2.5e0", 0xc100},
+		{"1E-7", 0x0002},
+		{"65519.99", 0x7bff},
+		{"null", 0x1234}, // unchanged
+		{`"NaN"`, 0x7e01},
+		{`"Infinity"`, 0x7c00},
+		{`"-Infinity"`, 0xfc00},
+	}
+	for _, tc := range testCases {
+		got := float16.Frombits(0x1234)
+		if err := got.UnmarshalJSON([]byte(tc.in)); err != nil {
+			t.Errorf("UnmarshalJSON(%q) returned error %v", tc.in, err)
+		}
+		if got.Bits() != tc.want {
+			t.Errorf("UnmarshalJSON(%q) returned 0x%04x, wanted 0x%04x", tc.in, got.Bits(), tc.want)
+		}
+	}
+
+	for _, in := range []string{"", "-", "+1", "01", "1.", ".5", "1e", "1e+", "0x10", "Inf", "NaN", `"1"`, `"nan"`, "1 "} {
+		var got float16.Float16
+		if err := got.UnmarshalJSON([]byte(in)); err != float16.ErrJSONSyntax {
+			t.Errorf("UnmarshalJSON(%q) returned error %v, wanted ErrJSONSyntax", in, err)
+		}
+	}
+
+	var got float16.Float16
+	if err := got.UnmarshalJSON([]byte("1e5")); !errors.Is(err, strconv.ErrRange) {
+		t.Errorf("UnmarshalJSON(1e5) returned error %v, wanted ErrRange", err)
+	}
+}
+
+func TestJSONStruct(t *testing.T) {
+	type embedding struct {
+		Scale  float16.Float16
+		Values []float16.Float16
+		Labels map[float16.Float16]string
+	}
+	in := embedding{
+		Scale:  float16.Fromfloat32(0.5),
+		Values: []float16.Float16{float16.Fromfloat32(1), float16.Fromfloat32(-0.1), float16.Fromfloat32(1e4)},
+		Labels: map[float16.Float16]string{float16.Fromfloat32(0.1): "a"},
+	}
+	b, err := json.Marshal(in)
+	if err != nil {
+		t.Fatalf("json.Marshal returned error %v", err)
+	}
+	if want := `{"Scale":0.5,"Values":[1,-0.1,10000],"Labels":{"0.1":"a"}}`; string(b) != want {
+		t.Errorf("json.Marshal returned %s, wanted %s", b, want)
+	}
+
+	var out embedding
+	if err := json.Unmarshal(b, &out); err != nil {
+		t.Fatalf("json.Unmarshal returned error %v", err)
+	}
+	if out.Scale != in.Scale || len(out.Values) != 3 || out.Values[1] != in.Values[1] || out.Labels[float16.Fromfloat32(0.1)] != "a" {
+		t.Errorf("json.Unmarshal returned %v, wanted %v", out, in)
+	}
+}
+
+// Test that JSONNull and JSONString let encoding/json encode NaN and infinity
+// in struct fields and decode them back.
+func TestJSONNonFiniteTypes(t *testing.T) {
+	type scores struct {
+		Null   []float16.JSONNull
+		String []float16.JSONString
+	}
+	in := scores{
+		Null:   []float16.JSONNull{float16.JSONNull(float16.Fromfloat32(0.5)), float16.JSONNull(float16.NaN()), float16.JSONNull(float16.Inf(1))},
+		String: []float16.JSONString{float16.JSONString(float16.Fromfloat32(0.5)), float16.JSONString(float16.NaN()), float16.JSONString(float16.Inf(-1))},
+	}
+	b, err := json.Marshal(in)
+	if err != nil {
+		t.Fatalf("json.Marshal returned error %v", err)
+	}
+	if want := `{"Null":[0.5,null,null],"String":[0.5,"NaN","-Infinity"]}`; string(b) != want {
+		t.Errorf("json.Marshal returned %s, wanted %s", b, want)
+	}
+
+	var out scores
+	if err := json.Unmarshal(b, &out); err != nil {
+		t.Fatalf("json.Unmarshal returned error %v", err)
+	}
+	if len(out.Null) != 3 || out.Null[0] != in.Null[0] || !float16.Float16(out.Null[1]).IsNaN() || !float16.Float16(out.Null[2]).IsNaN() {
+		t.Errorf("json.Unmarshal returned Null %v, wanted [0.5 NaN NaN]", out.Null)
+	}
+	if len(out.String) != 3 || out.String[0] != in.String[0] || !float16.Float16(out.String[1]).IsNaN() || out.String[2] != in.String[2] {
+		t.Errorf("json.Unmarshal returned String %v, wanted [0.5 NaN -Inf]", out.String)
+	}
+}
+
+func TestJSONSlice(t *testing.T) {
+	src := []float16.Float16{float16.Fromfloat32(0.1), float16.NaN(), float16.Inf(-1), float16.Frombits(0x8001)}
+
+	if _, err := float16.MarshalJSONSlice(src); err != float16.ErrJSONNonFinite {
+		t.Errorf("MarshalJSONSlice returned error %v, wanted ErrJSONNonFinite", err)
+	}
+	if _, err := float16.MarshalJSONSliceWith(src, float16.NonFiniteError); err != float16.ErrJSONNonFinite {
+		t.Errorf("MarshalJSONSliceWith(NonFiniteError) returned error %v, wanted ErrJSONNonFinite", err)
+	}
+
+	b, err := float16.MarshalJSONSliceWith(src, float16.NonFiniteNull)
+	if err != nil || string(b) != "[0.1,null,null,-6e-8]" {
+		t.Errorf("MarshalJSONSliceWith(NonFiniteNull) returned %s, %v", b, err)
+	}
+	got, err := float16.UnmarshalJSONSlice(b)
+	if err != nil || len(got) != 4 || got[0] != src[0] || !got[1].IsNaN() || !got[2].IsNaN() || got[3] != src[3] {
+		t.Errorf("UnmarshalJSONSlice(%s) returned %v, %v", b, got, err)
+	}
+
+	b, err = float16.MarshalJSONSliceWith(src, float16.NonFiniteString)
+	if err != nil || string(b) != `[0.1,"NaN","-Infinity",-6e-8]` {
+		t.Errorf("MarshalJSONSliceWith(NonFiniteString) returned %s, %v", b, err)
+	}
+	got, err = float16.UnmarshalJSONSlice(b)
+	if err != nil || len(got) != 4 || got[0] != src[0] || !got[1].IsNaN() || got[2] != src[2] || got[3] != src[3] {
+		t.Errorf("UnmarshalJSONSlice(%s) returned %v, %v", b, got, err)
+	}
+
+	b, err = float16.MarshalJSONSlice(nil)
+	if err != nil || string(b) != "null" {
+		t.Errorf("MarshalJSONSlice(nil) returned %s, %v", b, err)
+	}
+	b, err = float16.MarshalJSONSlice([]float16.Float16{})
+	if err != nil || string(b) != "[]" {
+		t.Errorf("MarshalJSONSlice([]) returned %s, %v", b, err)
+	}
+
+	testCases := []struct {
+		in   string
+		want []float16.Float16
+	}{
+		{"null", nil},
+		{" null\n", nil},
+		{"[]", []float16.Float16{}},
+		{" [ ] ", []float16.Float16{}},
+		{"[1]", []float16.Float16{0x3c00}},
+		{"\t[ 1 ,\r\n-2.5,  0 ]\n", []float16.Float16{0x3c00, 0xc100, 0x0000}},
+	}
+	for _, tc := range testCases {
+		got, err := float16.UnmarshalJSONSlice([]byte(tc.in))
+		if err != nil {
+			t.Errorf("UnmarshalJSONSlice(%q) returned error %v", tc.in, err)
+		}
+		if (got == nil) != (tc.want == nil) || len(got) != len(tc.want) {
+			t.Errorf("UnmarshalJSONSlice(%q) returned %v, wanted %v", tc.in, got, tc.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tc.want[i] {
+				t.Errorf("UnmarshalJSONSlice(%q) returned %v, wanted %v", tc.in, got, tc.want)
+			}
+		}
+	}
+
+	for _, in := range []string{"", "nul", "{}", "[", "[1", "[1,", "[1,]", "[,1]", "[1 2]", "[1]x", "[]x", "[1;2]", `["1"]`} {
+		if _, err := float16.UnmarshalJSONSlice([]byte(in)); err != float16.ErrJSONSyntax {
+			t.Errorf("UnmarshalJSONSlice(%q) returned error %v, wanted ErrJSONSyntax", in, err)
+		}
+	}
+	if _, err := float16.UnmarshalJSONSlice([]byte("[1e9]")); !errors.Is(err, strconv.ErrRange) {
+		t.Errorf("UnmarshalJSONSlice([1e9]) returned error %v, wanted ErrRange", err)
+	}
+}
@@ -0,0 +1,11 @@
+// Copyright 2019 Montgomery Edwards⁴⁴⁸ and Faye Amacker
+
+package mmap
+
+// SetMaxLength sets the length of the largest region and returns a function
+// that restores it.
+func SetMaxLength(n int64) (restore func()) {
+	old := maxLength
+	maxLength = n
+	return func() { maxLength = old }
+}
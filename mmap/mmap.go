@@ -0,0 +1,153 @@
+// Copyright 2019 Montgomery Edwards⁴⁴⁸ and Faye Amacker
+
+// Package mmap provides read-only access to arrays of Float16 values in files
+// by mapping the files into memory.  On Linux the values are read from the
+// page cache without copying.  On other systems they're read into memory.
+package mmap
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"os"
+	"unsafe"
+
+	"github.com/x448/float16"
+)
+
+// ErrAlignment indicates a region that doesn't start or end on a 2-byte boundary.
+var ErrAlignment = errors.New("float16/mmap: offset and length must be multiples of 2")
+
+// ErrRegion indicates a region that isn't within the file.
+var ErrRegion = errors.New("float16/mmap: region is outside the file")
+
+// ErrTooLarge indicates a region with more bytes than an int can hold, which
+// can happen on 32-bit systems.
+var ErrTooLarge = errors.New("float16/mmap: region is too large to map")
+
+// hostLittleEndian is true if the CPU stores the low byte of a uint16 first.
+var hostLittleEndian = func() bool {
+	x := uint16(1)
+	return *(*byte)(unsafe.Pointer(&x)) == 1
+}()
+
+// maxLength is the length of the largest region, which tests can lower.
+var maxLength int64 = math.MaxInt
+
+// An Array is a read-only array of Float16 values mapped from a file.  Its
+// methods are safe for concurrent use, except Close.  Changes to the file
+// while it's mapped can change the values, and truncating the file can crash
+// the program with SIGBUS.
+type Array struct {
+	data   []byte // the region; nil after Close
+	mapped []byte // the mapping returned by mapRegion, if any
+	order  binary.ByteOrder
+	vals   []float16.Float16 // data without copying, if the host's byte order is order
+}
+
+// Open maps the whole named file, which holds little-endian Float16 values.
+func Open(name string) (*Array, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return openRegion(f, 0, -1, binary.LittleEndian)
+}
+
+// OpenRegion maps length bytes of f starting at off, which hold Float16 values
+// in the specified byte order.  off and length must be multiples of 2.
+// f can be closed after OpenRegion returns.
+func OpenRegion(f *os.File, off, length int64, order binary.ByteOrder) (*Array, error) {
+	if length < 0 {
+		return nil, ErrRegion
+	}
+	return openRegion(f, off, length, order)
+}
+
+// openRegion is OpenRegion, with a negative length meaning the rest of the file.
+func openRegion(f *os.File, off, length int64, order binary.ByteOrder) (*Array, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if length < 0 {
+		length = fi.Size() - off
+	}
+	if off%2 != 0 || length%2 != 0 {
+		return nil, ErrAlignment
+	}
+	if off < 0 || off > fi.Size() || length > fi.Size()-off {
+		return nil, ErrRegion
+	}
+	if length > maxLength {
+		return nil, ErrTooLarge
+	}
+	a := &Array{data: []byte{}, order: order}
+	if length > 0 {
+		a.data, a.mapped, err = mapRegion(f, off, int(length))
+		if err != nil {
+			return nil, err
+		}
+	}
+	if hostLittleEndian && order == binary.LittleEndian && length > 0 {
+		// The region is aligned and the host is little-endian, so
+		// BytesToFloat16s doesn't copy.
+		a.vals, _ = float16.BytesToFloat16s(a.data)
+	}
+	return a, nil
+}
+
+// Len returns the number of values.
+func (a *Array) Len() int {
+	return len(a.data) / 2
+}
+
+// At returns the value at index i.  It panics if i is out of range.
+func (a *Array) At(i int) float16.Float16 {
+	if a.vals != nil {
+		return a.vals[i]
+	}
+	return float16.Float16(a.order.Uint16(a.data[2*i : 2*i+2]))
+}
+
+// Float16s copies values starting at index off into dst.  Like copy, it
+// copies min(len(dst), Len()-off) values and returns that number.  It panics
+// if off is out of range.
+func (a *Array) Float16s(dst []float16.Float16, off int) int {
+	if a.vals != nil {
+		return copy(dst, a.vals[off:])
+	}
+	return float16.DecodeSlice(dst, a.data[2*off:], a.order)
+}
+
+// Float32s converts values starting at index off to float32 like
+// float16.ToFloat32s, and stores them in dst.  Like copy, it converts
+// min(len(dst), Len()-off) values and returns that number.  It panics if off
+// is out of range.
+func (a *Array) Float32s(dst []float32, off int) int {
+	if a.vals != nil {
+		return float16.ToFloat32s(dst, a.vals[off:])
+	}
+	var buf [1024]float16.Float16
+	n := 0
+	for n < len(dst) {
+		k := float16.DecodeSlice(buf[:], a.data[2*(off+n):], a.order)
+		if k == 0 {
+			break
+		}
+		n += float16.ToFloat32s(dst[n:], buf[:k])
+	}
+	return n
+}
+
+// Close unmaps the file.  The Array can't be used after Close.
+func (a *Array) Close() error {
+	a.data, a.vals = nil, nil
+	if a.mapped == nil {
+		return nil
+	}
+	m := a.mapped
+	a.mapped = nil
+	return unmap(m)
+}
@@ -0,0 +1,28 @@
+// Copyright 2019 Montgomery Edwards⁴⁴⁸ and Faye Amacker
+
+package mmap
+
+import (
+	"os"
+	"syscall"
+)
+
+// mapRegion maps length bytes of f starting at off, and returns the region
+// and the whole mapping, which starts at a page boundary.
+func mapRegion(f *os.File, off int64, length int) (data, mapped []byte, err error) {
+	start := off &^ int64(os.Getpagesize()-1)
+	skip := int(off - start)
+	if int64(length) > maxLength-int64(skip) {
+		return nil, nil, ErrTooLarge
+	}
+	mapped, err = syscall.Mmap(int(f.Fd()), start, skip+length, syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, nil, os.NewSyscallError("mmap", err)
+	}
+	return mapped[skip : skip+length : skip+length], mapped, nil
+}
+
+// unmap unmaps a mapping returned by mapRegion.
+func unmap(mapped []byte) error {
+	return os.NewSyscallError("munmap", syscall.Munmap(mapped))
+}
@@ -0,0 +1,24 @@
+// Copyright 2019 Montgomery Edwards⁴⁴⁸ and Faye Amacker
+
+//go:build !linux
+
+package mmap
+
+import (
+	"os"
+)
+
+// mapRegion reads length bytes of f starting at off, because mapping isn't
+// implemented on this system.
+func mapRegion(f *os.File, off int64, length int) (data, mapped []byte, err error) {
+	data = make([]byte, length)
+	if _, err := f.ReadAt(data, off); err != nil {
+		return nil, nil, err
+	}
+	return data, nil, nil
+}
+
+// unmap isn't called because mapRegion doesn't map.
+func unmap(mapped []byte) error {
+	return nil
+}
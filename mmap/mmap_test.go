@@ -0,0 +1,192 @@
+// Copyright 2019 Montgomery Edwards⁴⁴⁸ and Faye Amacker
+
+package mmap_test
+
+import (
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/x448/float16"
+	"github.com/x448/float16/mmap"
+)
+
+// writeFile writes vals to a new file in the specified byte order, after a
+// header of skip bytes, and returns the file's name.
+func writeFile(t *testing.T, vals []float16.Float16, skip int, order binary.ByteOrder) string {
+	t.Helper()
+	b := make([]byte, skip+2*len(vals))
+	float16.EncodeSlice(b[skip:], vals, order)
+	name := filepath.Join(t.TempDir(), "vals.f16")
+	if err := os.WriteFile(name, b, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
+
+func testVals(n int) []float16.Float16 {
+	vals := make([]float16.Float16, n)
+	for i := range vals {
+		vals[i] = float16.Frombits(uint16(i * 7))
+	}
+	return vals
+}
+
+// checkArray checks all methods of a that read values against want.
+func checkArray(t *testing.T, a *mmap.Array, want []float16.Float16) {
+	t.Helper()
+	if a.Len() != len(want) {
+		t.Fatalf("Len() = %d, wanted %d", a.Len(), len(want))
+	}
+	for i, f16 := range want {
+		if got := a.At(i); got != f16 {
+			t.Fatalf("At(%d) = 0x%04x, wanted 0x%04x", i, got.Bits(), f16.Bits())
+		}
+	}
+
+	off := len(want) / 3
+	f16s := make([]float16.Float16, len(want))
+	if n := a.Float16s(f16s, off); n != len(want)-off {
+		t.Fatalf("Float16s returned %d, wanted %d", n, len(want)-off)
+	}
+	f32s := make([]float32, len(want))
+	if n := a.Float32s(f32s, off); n != len(want)-off {
+		t.Fatalf("Float32s returned %d, wanted %d", n, len(want)-off)
+	}
+	for i, f16 := range want[off:] {
+		if f16s[i] != f16 {
+			t.Fatalf("Float16s [%d] = 0x%04x, wanted 0x%04x", off+i, f16s[i].Bits(), f16.Bits())
+		}
+		if math.Float32bits(f32s[i]) != math.Float32bits(f16.Float32()) {
+			t.Fatalf("Float32s [%d] = 0x%08x, wanted 0x%08x", off+i, math.Float32bits(f32s[i]), math.Float32bits(f16.Float32()))
+		}
+	}
+	if n := a.Float32s(f32s[:5], 0); n != 5 {
+		t.Fatalf("Float32s returned %d, wanted 5", n)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	want := testVals(10000)
+	a, err := mmap.Open(writeFile(t, want, 0, binary.LittleEndian))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	checkArray(t, a, want)
+	if err := a.Close(); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+	if err := a.Close(); err != nil {
+		t.Errorf("second Close: %v", err)
+	}
+
+	if _, err := mmap.Open(filepath.Join(t.TempDir(), "missing")); !os.IsNotExist(err) {
+		t.Errorf("Open(missing) = %v, wanted not exist", err)
+	}
+	if _, err := mmap.Open(writeFile(t, want, 1, binary.LittleEndian)); err != mmap.ErrAlignment {
+		t.Errorf("Open(odd size) = %v, wanted %v", err, mmap.ErrAlignment)
+	}
+}
+
+func TestOpenRegion(t *testing.T) {
+	want := testVals(5000)
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		// A header that isn't a multiple of the page size.
+		const skip = 4102
+		f, err := os.Open(writeFile(t, append(want, 0x7c00), skip, order))
+		if err != nil {
+			t.Fatal(err)
+		}
+		a, err := mmap.OpenRegion(f, skip, int64(2*len(want)), order)
+		f.Close()
+		if err != nil {
+			t.Fatalf("%v OpenRegion: %v", order, err)
+		}
+		checkArray(t, a, want)
+		if err := a.Close(); err != nil {
+			t.Errorf("%v Close: %v", order, err)
+		}
+	}
+}
+
+func TestOpenRegionEmpty(t *testing.T) {
+	f, err := os.Open(writeFile(t, nil, 0, binary.LittleEndian))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	a, err := mmap.OpenRegion(f, 0, 0, binary.LittleEndian)
+	if err != nil {
+		t.Fatalf("OpenRegion: %v", err)
+	}
+	if n := a.Float32s(make([]float32, 4), 0); a.Len() != 0 || n != 0 {
+		t.Errorf("Len() = %d, Float32s returned %d, wanted 0, 0", a.Len(), n)
+	}
+	if err := a.Close(); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+}
+
+func TestOpenRegionErrors(t *testing.T) {
+	name := writeFile(t, testVals(8), 0, binary.LittleEndian)
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	for _, tc := range []struct {
+		off, length int64
+		err         error
+	}{
+		{1, 2, mmap.ErrAlignment},
+		{0, 3, mmap.ErrAlignment},
+		{-2, 2, mmap.ErrRegion},
+		{0, -2, mmap.ErrRegion},
+		{0, 18, mmap.ErrRegion},
+		{18, 0, mmap.ErrRegion},
+		{14, 4, mmap.ErrRegion},
+	} {
+		if _, err := mmap.OpenRegion(f, tc.off, tc.length, binary.LittleEndian); err != tc.err {
+			t.Errorf("OpenRegion(%d, %d) = %v, wanted %v", tc.off, tc.length, err, tc.err)
+		}
+	}
+
+	// Regions larger than an int, as on 32-bit systems.
+	restore := mmap.SetMaxLength(14)
+	if _, err := mmap.OpenRegion(f, 0, 16, binary.LittleEndian); err != mmap.ErrTooLarge {
+		t.Errorf("OpenRegion(too large) = %v, wanted %v", err, mmap.ErrTooLarge)
+	}
+	restore()
+
+	// Mappings start at a page boundary, so they also hold the bytes before off.
+	if runtime.GOOS == "linux" {
+		restore = mmap.SetMaxLength(14)
+		if _, err := mmap.OpenRegion(f, 2, 14, binary.LittleEndian); err != mmap.ErrTooLarge {
+			t.Errorf("OpenRegion(too large with offset) = %v, wanted %v", err, mmap.ErrTooLarge)
+		}
+		restore()
+	}
+
+	// Mapping needs read access.
+	w, err := os.OpenFile(name, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	if _, err := mmap.OpenRegion(w, 0, 16, binary.LittleEndian); err == nil {
+		t.Errorf("OpenRegion(write-only file) = nil, wanted error")
+	}
+
+	closed, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed.Close()
+	if _, err := mmap.OpenRegion(closed, 0, 16, binary.LittleEndian); err == nil {
+		t.Errorf("OpenRegion(closed file) = nil, wanted error")
+	}
+}
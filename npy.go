@@ -0,0 +1,370 @@
+// Copyright 2019 Montgomery Edwards⁴⁴⁸ and Faye Amacker
+
+package float16
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ErrNpyHeader indicates ReadNpy received data that isn't a valid .npy file.
+const ErrNpyHeader = float16Error("float16: invalid .npy header")
+
+// ErrNpyDtype indicates ReadNpy received an array that isn't float16, float32 or float64.
+const ErrNpyDtype = float16Error("float16: unsupported .npy dtype, expected f2, f4 or f8")
+
+// ErrNpyShape indicates a .npy shape with a negative or too large dimension,
+// or one that doesn't match the length of the data passed to WriteNpy.
+const ErrNpyShape = float16Error("float16: invalid .npy shape")
+
+// npyMagic starts every .npy file, followed by the major and minor version.
+const npyMagic = "\x93NUMPY"
+
+// maxNpyValues limits the number of values in a shape, so the byte size of
+// the array fits in an int.
+const maxNpyValues = math.MaxInt / 2
+
+// ReadNpy reads a NumPy .npy file from r and returns its values and shape.
+// Files with version 1, 2 and 3 headers are accepted.  The dtype must be
+// float16 ('<f2' or '>f2'), or float32 or float64 in either byte order, which
+// are converted like Fromfloat32 and Fromfloat64.  Arrays in Fortran order
+// are returned in C order, so the last index of shape varies fastest.
+//
+// ReadNpy reads no further than the end of the array.  It returns
+// io.ErrUnexpectedEOF if r ends before that.
+func ReadNpy(r io.Reader) (data []Float16, shape []int, err error) {
+	var prefix [len(npyMagic) + 2]byte
+	if _, err := io.ReadFull(r, prefix[:]); err != nil {
+		return nil, nil, npyEOF(err)
+	}
+	if string(prefix[:len(npyMagic)]) != npyMagic {
+		return nil, nil, ErrNpyHeader
+	}
+
+	var headerLen int
+	switch major := prefix[len(npyMagic)]; major {
+	case 1:
+		var b [2]byte
+		if _, err := io.ReadFull(r, b[:]); err != nil {
+			return nil, nil, npyEOF(err)
+		}
+		headerLen = int(binary.LittleEndian.Uint16(b[:]))
+	case 2, 3:
+		var b [4]byte
+		if _, err := io.ReadFull(r, b[:]); err != nil {
+			return nil, nil, npyEOF(err)
+		}
+		headerLen = int(binary.LittleEndian.Uint32(b[:]))
+		if headerLen > 1<<20 {
+			return nil, nil, ErrNpyHeader
+		}
+	default:
+		return nil, nil, ErrNpyHeader
+	}
+	header := make([]byte, headerLen)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, nil, npyEOF(err)
+	}
+	descr, fortran, shape, err := parseNpyHeader(string(header))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	n := 1
+	for _, dim := range shape {
+		if dim < 0 || dim != 0 && n > maxNpyValues/dim {
+			return nil, nil, ErrNpyShape
+		}
+		n *= dim
+	}
+
+	var order binary.ByteOrder
+	switch descr[0] {
+	case '<':
+		order = binary.LittleEndian
+	case '>':
+		order = binary.BigEndian
+	default:
+		return nil, nil, ErrNpyDtype
+	}
+	switch descr[1:] {
+	case "f2":
+		data, err = readNpyFloat16s(io.LimitReader(r, 2*int64(n)), order, n)
+	case "f4", "f8":
+		size := int(descr[2] - '0')
+		data, err = readNpyFloats(io.LimitReader(r, int64(size)*int64(n)), order, size, n)
+	default:
+		return nil, nil, ErrNpyDtype
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if fortran {
+		data = npyFortranToC(data, shape)
+	}
+	return data, shape, nil
+}
+
+// npyEOF returns io.ErrUnexpectedEOF instead of io.EOF, because a .npy file
+// can't end before its array does.
+func npyEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// npyChunk is the most values ReadNpy allocates before reading them, so a
+// header can't make it allocate much more than r holds.
+const npyChunk = 1 << 16
+
+// readNpyFloat16s reads n Float16 values in the specified byte order.
+func readNpyFloat16s(r io.Reader, order binary.ByteOrder, n int) ([]Float16, error) {
+	d := NewDecoder(r, order)
+	data := make([]Float16, minInt(n, npyChunk))
+	for got := 0; got < n; {
+		if got == len(data) {
+			data = append(data, make([]Float16, minInt(n-got, len(data)))...)
+		}
+		k, err := d.ReadFloat16s(data[got:])
+		got += k
+		if err != nil {
+			return nil, npyEOF(err)
+		}
+	}
+	return data, nil
+}
+
+// readNpyFloats reads n float32 or float64 values, each size bytes in the
+// specified byte order, and converts them to Float16.
+func readNpyFloats(r io.Reader, order binary.ByteOrder, size, n int) ([]Float16, error) {
+	data := make([]Float16, 0, minInt(n, npyChunk))
+	buf := make([]byte, size*minInt(n, npyChunk))
+	for len(data) < n {
+		b := buf[:size*minInt(n-len(data), npyChunk)]
+		if _, err := io.ReadFull(r, b); err != nil {
+			return nil, npyEOF(err)
+		}
+		for i := 0; i < len(b); i += size {
+			if size == 4 {
+				data = append(data, Fromfloat32(math.Float32frombits(order.Uint32(b[i:]))))
+			} else {
+				data = append(data, Fromfloat64(math.Float64frombits(order.Uint64(b[i:]))))
+			}
+		}
+	}
+	return data, nil
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// npyFortranToC returns data, which is in Fortran order with the first index
+// varying fastest, in C order with the last index varying fastest.
+func npyFortranToC(data []Float16, shape []int) []Float16 {
+	if len(shape) < 2 || len(data) == 0 {
+		return data
+	}
+	// strides[k] is the C order distance between values whose index k
+	// differs by 1.
+	strides := make([]int, len(shape))
+	stride := 1
+	for k := len(shape) - 1; k >= 0; k-- {
+		strides[k] = stride
+		stride *= shape[k]
+	}
+
+	out := make([]Float16, len(data))
+	index := make([]int, len(shape))
+	c := 0 // C order position of index
+	for _, f := range data {
+		out[c] = f
+		for k := range index {
+			index[k]++
+			c += strides[k]
+			if index[k] < shape[k] {
+				break
+			}
+			c -= shape[k] * strides[k]
+			index[k] = 0
+		}
+	}
+	return out
+}
+
+// parseNpyHeader parses the Python dict literal in a .npy header, such as
+// {'descr': '<f2', 'fortran_order': False, 'shape': (2, 3), }.
+func parseNpyHeader(h string) (descr string, fortran bool, shape []int, err error) {
+	p := npyParser{s: strings.TrimRight(h, " \n")}
+	var seen [3]bool
+	ok := p.consume('{')
+	for ok && !p.consume('}') {
+		var key string
+		key, ok = p.str()
+		if ok = ok && p.consume(':'); !ok {
+			break
+		}
+		switch key {
+		case "descr":
+			descr, ok = p.str()
+			ok = ok && len(descr) >= 2 && !seen[0]
+			seen[0] = true
+		case "fortran_order":
+			fortran, ok = p.bool()
+			ok = ok && !seen[1]
+			seen[1] = true
+		case "shape":
+			shape, ok = p.tuple()
+			ok = ok && !seen[2]
+			seen[2] = true
+		default:
+			ok = false
+		}
+		if ok && !p.consume(',') {
+			ok = p.consume('}')
+			break
+		}
+	}
+	if !ok || p.s != "" || !seen[0] || !seen[1] || !seen[2] {
+		return "", false, nil, ErrNpyHeader
+	}
+	return descr, fortran, shape, nil
+}
+
+// npyParser parses the subset of Python literals used in .npy headers.
+type npyParser struct {
+	s string // unparsed input
+}
+
+// consume skips spaces and c, and reports whether c was found.
+func (p *npyParser) consume(c byte) bool {
+	p.s = strings.TrimLeft(p.s, " ")
+	if p.s == "" || p.s[0] != c {
+		return false
+	}
+	p.s = p.s[1:]
+	return true
+}
+
+// str parses a string in single or double quotes without escapes.
+func (p *npyParser) str() (string, bool) {
+	p.s = strings.TrimLeft(p.s, " ")
+	if p.s == "" || p.s[0] != '\'' && p.s[0] != '"' {
+		return "", false
+	}
+	end := strings.IndexByte(p.s[1:], p.s[0])
+	if end < 0 {
+		return "", false
+	}
+	s := p.s[1 : 1+end]
+	p.s = p.s[2+end:]
+	return s, true
+}
+
+// bool parses True or False.
+func (p *npyParser) bool() (bool, bool) {
+	p.s = strings.TrimLeft(p.s, " ")
+	switch {
+	case strings.HasPrefix(p.s, "True"):
+		p.s = p.s[len("True"):]
+		return true, true
+	case strings.HasPrefix(p.s, "False"):
+		p.s = p.s[len("False"):]
+		return false, true
+	}
+	return false, false
+}
+
+// tuple parses a tuple of non-negative ints, such as (), (3,) or (2, 3).
+// Ints may have the L suffix written by Python 2.
+func (p *npyParser) tuple() ([]int, bool) {
+	if !p.consume('(') {
+		return nil, false
+	}
+	shape := []int{}
+	for !p.consume(')') {
+		p.s = strings.TrimLeft(p.s, " ")
+		end := strings.IndexFunc(p.s, func(r rune) bool { return r < '0' || r > '9' })
+		if end <= 0 {
+			return nil, false
+		}
+		dim, err := strconv.Atoi(p.s[:end])
+		if err != nil {
+			return nil, false
+		}
+		shape = append(shape, dim)
+		p.s = strings.TrimPrefix(p.s[end:], "L")
+		if !p.consume(',') {
+			if !p.consume(')') {
+				return nil, false
+			}
+			break
+		}
+	}
+	return shape, true
+}
+
+// WriteNpy writes data to w as a NumPy .npy file of little-endian float16
+// ('<f2') in C order with the specified shape.  The product of shape must be
+// len(data), so a scalar has an empty shape.  It writes a version 1 header,
+// or version 2 if the shape is too long for version 1.
+func WriteNpy(w io.Writer, data []Float16, shape []int) error {
+	n := 1
+	for _, dim := range shape {
+		if dim < 0 || dim != 0 && n > maxNpyValues/dim {
+			return ErrNpyShape
+		}
+		n *= dim
+	}
+	if n != len(data) {
+		return ErrNpyShape
+	}
+
+	var sb strings.Builder
+	sb.WriteString("{'descr': '<f2', 'fortran_order': False, 'shape': (")
+	for i, dim := range shape {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(strconv.Itoa(dim))
+	}
+	if len(shape) == 1 {
+		sb.WriteByte(',')
+	}
+	sb.WriteString("), }")
+
+	// Pad the header with spaces and a newline so the array starts at a
+	// multiple of 64 bytes.
+	version, prefixLen := byte(1), len(npyMagic)+4
+	pad := 63 - (prefixLen+sb.Len())%64
+	if sb.Len()+pad+1 > math.MaxUint16 {
+		version, prefixLen = 2, len(npyMagic)+6
+		pad = 63 - (prefixLen+sb.Len())%64
+	}
+	headerLen := sb.Len() + pad + 1
+	header := make([]byte, 0, prefixLen+headerLen)
+	header = append(header, npyMagic...)
+	header = append(header, version, 0)
+	if version == 1 {
+		header = append(header, byte(headerLen), byte(headerLen>>8))
+	} else {
+		header = append(header, byte(headerLen), byte(headerLen>>8), byte(headerLen>>16), byte(headerLen>>24))
+	}
+	header = append(header, sb.String()...)
+	header = append(header, strings.Repeat(" ", pad)...)
+	header = append(header, '\n')
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+
+	e := NewEncoder(w, binary.LittleEndian)
+	e.WriteFloat16s(data)
+	return e.Close()
+}
@@ -0,0 +1,254 @@
+// Copyright 2019 Montgomery Edwards⁴⁴⁸ and Faye Amacker
+
+package float16_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/x448/float16"
+)
+
+// appendUint appends the size low bytes of v in the specified byte order.
+func appendUint(b []byte, order binary.ByteOrder, size int, v uint64) []byte {
+	var buf [8]byte
+	switch size {
+	case 2:
+		order.PutUint16(buf[:], uint16(v))
+	case 4:
+		order.PutUint32(buf[:], uint32(v))
+	default:
+		order.PutUint64(buf[:], v)
+	}
+	return append(b, buf[:size]...)
+}
+
+// npyFile returns a .npy file with the specified version, header and data.
+func npyFile(version byte, header string, data []byte) []byte {
+	b := []byte("\x93NUMPY")
+	b = append(b, version, 0)
+	if version == 1 {
+		b = appendUint(b, binary.LittleEndian, 2, uint64(len(header)))
+	} else {
+		b = appendUint(b, binary.LittleEndian, 4, uint64(len(header)))
+	}
+	b = append(b, header...)
+	return append(b, data...)
+}
+
+func TestWriteNpy(t *testing.T) {
+	// Written by numpy.save(f, numpy.array([1, 2, 3], dtype='<f2')).
+	header := "{'descr': '<f2', 'fortran_order': False, 'shape': (3,), }"
+	header += strings.Repeat(" ", 118-1-len(header)) + "\n"
+	want := npyFile(1, header, []byte{0x00, 0x3c, 0x00, 0x40, 0x00, 0x42})
+
+	var buf bytes.Buffer
+	if err := float16.WriteNpy(&buf, []float16.Float16{0x3c00, 0x4000, 0x4200}, []int{3}); err != nil {
+		t.Fatalf("WriteNpy: %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("WriteNpy wrote\n%q\nwanted\n%q", buf.Bytes(), want)
+	}
+}
+
+func TestNpyRoundTrip(t *testing.T) {
+	for _, shape := range [][]int{{}, {0}, {5}, {2, 3}, {2, 0, 4}, {3, 4, 5}, {70000}, make([]int, 30000)} {
+		n := 1
+		for _, dim := range shape {
+			n *= dim
+		}
+		data := make([]float16.Float16, n)
+		for i := range data {
+			data[i] = float16.Frombits(uint16(i * 5))
+		}
+
+		var buf bytes.Buffer
+		if err := float16.WriteNpy(&buf, data, shape); err != nil {
+			t.Fatalf("WriteNpy(shape %d dims): %v", len(shape), err)
+		}
+		if buf.Len()%64 != len(data)*2%64 {
+			t.Errorf("WriteNpy(shape %d dims) data isn't 64-byte aligned", len(shape))
+		}
+		got, gotShape, err := float16.ReadNpy(&buf)
+		if err != nil {
+			t.Fatalf("ReadNpy(shape %d dims): %v", len(shape), err)
+		}
+		if !reflect.DeepEqual(gotShape, shape) || len(got) != len(data) {
+			t.Fatalf("ReadNpy shape %v, len %d, wanted %v, %d", gotShape, len(got), shape, len(data))
+		}
+		for i := range data {
+			if got[i] != data[i] {
+				t.Fatalf("ReadNpy [%d] = 0x%04x, wanted 0x%04x", i, got[i].Bits(), data[i].Bits())
+			}
+		}
+	}
+}
+
+func TestReadNpyDtypes(t *testing.T) {
+	f64s := []float64{1, -0.5, 65520, 1e-8, math.Inf(-1), 0x1.002p0, 0x1.006p0}
+	want := []float16.Float16{0x3c00, 0xb800, 0x7c00, 0x0000, 0xfc00, 0x3c00, 0x3c02}
+
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		c := map[binary.ByteOrder]string{binary.LittleEndian: "<", binary.BigEndian: ">"}[order]
+		var f2, f4, f8 []byte
+		for i, f := range f64s {
+			f2 = appendUint(f2, order, 2, uint64(want[i].Bits()))
+			f4 = appendUint(f4, order, 4, uint64(math.Float32bits(float32(f))))
+			f8 = appendUint(f8, order, 8, math.Float64bits(f))
+		}
+		for dtype, data := range map[string][]byte{"f2": f2, "f4": f4, "f8": f8} {
+			header := "{'descr': '" + c + dtype + "', 'fortran_order': False, 'shape': (7,), }\n"
+			for version := byte(1); version <= 3; version++ {
+				got, shape, err := float16.ReadNpy(bytes.NewReader(npyFile(version, header, data)))
+				if err != nil {
+					t.Fatalf("ReadNpy(%s%s v%d): %v", c, dtype, version, err)
+				}
+				if !reflect.DeepEqual(got, want) || !reflect.DeepEqual(shape, []int{7}) {
+					t.Errorf("ReadNpy(%s%s v%d) = %v %v, wanted %v [7]", c, dtype, version, got, shape, want)
+				}
+			}
+		}
+	}
+}
+
+func TestReadNpyLarge(t *testing.T) {
+	const n = 70000
+	data := make([]byte, 4*n)
+	for i := 0; i < n; i++ {
+		binary.LittleEndian.PutUint32(data[4*i:], math.Float32bits(float32(i)))
+	}
+	header := "{'descr': '<f4', 'fortran_order': False, 'shape': (70000,), }\n"
+	got, _, err := float16.ReadNpy(bytes.NewReader(npyFile(1, header, data)))
+	if err != nil {
+		t.Fatalf("ReadNpy: %v", err)
+	}
+	for i := range got {
+		if want := float16.Fromfloat32(float32(i)); got[i] != want {
+			t.Fatalf("ReadNpy [%d] = 0x%04x, wanted 0x%04x", i, got[i].Bits(), want.Bits())
+		}
+	}
+}
+
+func TestReadNpyFortranOrder(t *testing.T) {
+	// a[i, j, k] = 100*i + 10*j + k stored with i varying fastest.
+	shape := []int{2, 3, 2}
+	var data []byte
+	for k := 0; k < 2; k++ {
+		for j := 0; j < 3; j++ {
+			for i := 0; i < 2; i++ {
+				data = appendUint(data, binary.LittleEndian, 2, uint64(float16.Fromfloat32(float32(100*i+10*j+k)).Bits()))
+			}
+		}
+	}
+	header := `{"descr": "<f2", "fortran_order": True, "shape": (2L, 3L, 2L)}`
+	got, gotShape, err := float16.ReadNpy(bytes.NewReader(npyFile(1, header, data)))
+	if err != nil {
+		t.Fatalf("ReadNpy: %v", err)
+	}
+	if !reflect.DeepEqual(gotShape, shape) {
+		t.Fatalf("ReadNpy shape = %v, wanted %v", gotShape, shape)
+	}
+	c := 0
+	for i := 0; i < 2; i++ {
+		for j := 0; j < 3; j++ {
+			for k := 0; k < 2; k++ {
+				if want := float32(100*i + 10*j + k); got[c].Float32() != want {
+					t.Errorf("ReadNpy [%d, %d, %d] = %v, wanted %v", i, j, k, got[c], want)
+				}
+				c++
+			}
+		}
+	}
+
+	// One-dimensional and empty arrays are the same in both orders.
+	header = "{'descr': '<f2', 'fortran_order': True, 'shape': (2, 0), }"
+	if got, _, err := float16.ReadNpy(bytes.NewReader(npyFile(1, header, nil))); err != nil || len(got) != 0 {
+		t.Errorf("ReadNpy(empty) = %v, %v, wanted [], nil", got, err)
+	}
+}
+
+func TestReadNpyErrors(t *testing.T) {
+	valid := npyFile(1, "{'descr': '<f2', 'fortran_order': False, 'shape': (2,), }", []byte{0, 0x3c, 0, 0x3c})
+	for i := 0; i < len(valid); i++ {
+		if _, _, err := float16.ReadNpy(bytes.NewReader(valid[:i])); err != io.ErrUnexpectedEOF {
+			t.Fatalf("ReadNpy(valid[:%d]) = %v, wanted %v", i, err, io.ErrUnexpectedEOF)
+		}
+	}
+	v2 := npyFile(2, "{'descr': '<f8', 'fortran_order': False, 'shape': (2,), }", make([]byte, 16))
+	for _, i := range []int{9, 11, len(v2) - 1} {
+		if _, _, err := float16.ReadNpy(bytes.NewReader(v2[:i])); err != io.ErrUnexpectedEOF {
+			t.Errorf("ReadNpy(v2[:%d]) = %v, wanted %v", i, err, io.ErrUnexpectedEOF)
+		}
+	}
+
+	for _, tc := range []struct {
+		file []byte
+		err  error
+	}{
+		{[]byte("\x93NUMPX\x01\x00\x00\x00"), float16.ErrNpyHeader},
+		{[]byte("\x93NUMPY\x04\x00\x00\x00"), float16.ErrNpyHeader},
+		{[]byte("\x93NUMPY\x02\x00\x00\x00\x00\x01"), float16.ErrNpyHeader},
+		{npyFile(1, "{'descr': '<i2', 'fortran_order': False, 'shape': (2,), }", nil), float16.ErrNpyDtype},
+		{npyFile(1, "{'descr': '|f2', 'fortran_order': False, 'shape': (2,), }", nil), float16.ErrNpyDtype},
+		{npyFile(1, "{'descr': '<f16', 'fortran_order': False, 'shape': (2,), }", nil), float16.ErrNpyDtype},
+		{npyFile(1, "{'descr': '<f2', 'fortran_order': False, 'shape': (99999, 99999, 99999, 99999), }", nil), float16.ErrNpyShape},
+		{npyFile(1, "{'descr': '<f2', 'fortran_order': False, 'shape': (0, 99999, 99999), }", nil), nil},
+	} {
+		if _, _, err := float16.ReadNpy(bytes.NewReader(tc.file)); err != tc.err {
+			t.Errorf("ReadNpy(%q) = %v, wanted %v", tc.file, err, tc.err)
+		}
+	}
+}
+
+func TestReadNpyHeaderErrors(t *testing.T) {
+	for _, header := range []string{
+		"",
+		"[]",
+		"{}",
+		"{'descr': '<f2', 'fortran_order': False}",
+		"{'descr': '<f2', 'shape': (2,)}",
+		"{'fortran_order': False, 'shape': (2,)}",
+		"{'descr': '<f2', 'fortran_order': False, 'shape': (2,), 'extra': 1}",
+		"{'descr': '<f2', 'descr': '<f2', 'fortran_order': False, 'shape': (2,)}",
+		"{'descr': '<f2', 'fortran_order': False, 'fortran_order': False, 'shape': (2,)}",
+		"{'descr': '<f2', 'fortran_order': False, 'shape': (2,), 'shape': (2,)}",
+		"{'descr': '<f2', 'fortran_order': False, 'shape': (2,)} x",
+		"{'descr': '<f2', 'fortran_order': False, 'shape': (2,) 'x'}",
+		"{'descr' '<f2', 'fortran_order': False, 'shape': (2,)}",
+		"{descr: '<f2', 'fortran_order': False, 'shape': (2,)}",
+		"{'descr: '<f2', 'fortran_order': False, 'shape': (2,)}",
+		"{'descr': 'f', 'fortran_order': False, 'shape': (2,)}",
+		"{'descr': '<f2', 'fortran_order': 0, 'shape': (2,)}",
+		"{'descr': '<f2', 'fortran_order': False, 'shape': [2]}",
+		"{'descr': '<f2', 'fortran_order': False, 'shape': (-2,)}",
+		"{'descr': '<f2', 'fortran_order': False, 'shape': (2 3)}",
+		"{'descr': '<f2', 'fortran_order': False, 'shape': (99999999999999999999,)}",
+		"{'descr': '<f2', 'fortran_order': False, 'shape': (2,",
+		"{'descr': '<f2",
+	} {
+		if _, _, err := float16.ReadNpy(bytes.NewReader(npyFile(1, header, nil))); err != float16.ErrNpyHeader {
+			t.Errorf("ReadNpy(%q) = %v, wanted %v", header, err, float16.ErrNpyHeader)
+		}
+	}
+}
+
+func TestWriteNpyErrors(t *testing.T) {
+	data := make([]float16.Float16, 6)
+	for _, shape := range [][]int{nil, {5}, {2, 2}, {-2, -3}} {
+		if err := float16.WriteNpy(io.Discard, data, shape); err != float16.ErrNpyShape {
+			t.Errorf("WriteNpy(6 values, shape %v) = %v, wanted %v", shape, err, float16.ErrNpyShape)
+		}
+	}
+	// The product of this shape wraps around to 0 in an int.
+	if err := float16.WriteNpy(io.Discard, nil, []int{8, math.MaxInt/4 + 1}); err != float16.ErrNpyShape {
+		t.Errorf("WriteNpy(no values, overflowing shape) = %v, wanted %v", err, float16.ErrNpyShape)
+	}
+	if err := float16.WriteNpy(errWriter{}, data, []int{6}); err == nil {
+		t.Errorf("WriteNpy(errWriter) = nil, wanted error")
+	}
+}
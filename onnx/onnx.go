@@ -0,0 +1,140 @@
+// Copyright 2019 Montgomery Edwards⁴⁴⁸ and Faye Amacker
+
+// Package onnx converts Float16 tensors to and from the fields of an ONNX
+// TensorProto without depending on protobuf.
+//
+// A FLOAT16 TensorProto stores its values either in raw_data, 2 little-endian
+// bytes per value, or in int32_data, one value per int32 holding the
+// zero-extended bits of the Float16.  See
+// https://github.com/onnx/onnx/blob/main/onnx/onnx.proto.
+package onnx
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/x448/float16"
+)
+
+// DataTypeFloat16 is the TensorProto.DataType of FLOAT16 tensors.
+const DataTypeFloat16 int32 = 10
+
+var (
+	// ErrRawDataLength indicates raw_data with an odd number of bytes.
+	ErrRawDataLength = errors.New("float16/onnx: raw_data length is odd")
+
+	// ErrInt32Data indicates an int32_data element outside the range of uint16.
+	ErrInt32Data = errors.New("float16/onnx: int32_data element out of range")
+
+	// ErrDims indicates dims that are negative or don't match the number of values.
+	ErrDims = errors.New("float16/onnx: dims don't match data")
+)
+
+// RawData returns the values of s as raw_data.
+func RawData(s []float16.Float16) []byte {
+	b := make([]byte, 2*len(s))
+	float16.EncodeSlice(b, s, binary.LittleEndian)
+	return b
+}
+
+// FromRawData returns the values stored in raw_data b.
+func FromRawData(b []byte) ([]float16.Float16, error) {
+	if len(b)%2 != 0 {
+		return nil, ErrRawDataLength
+	}
+	s := make([]float16.Float16, len(b)/2)
+	float16.DecodeSlice(s, b, binary.LittleEndian)
+	return s, nil
+}
+
+// Int32Data returns the values of s as int32_data.  Each element holds the
+// zero-extended bits of one value, so -1 (0xbc00) is stored as 48128.
+func Int32Data(s []float16.Float16) []int32 {
+	d := make([]int32, len(s))
+	for i, f := range s {
+		d[i] = int32(f.Bits())
+	}
+	return d
+}
+
+// FromInt32Data returns the values stored in int32_data d.  It returns
+// ErrInt32Data if an element isn't in [0, 65535], such as bits that were
+// sign-extended.
+func FromInt32Data(d []int32) ([]float16.Float16, error) {
+	s := make([]float16.Float16, len(d))
+	for i, v := range d {
+		if uint32(v) > math.MaxUint16 {
+			return nil, ErrInt32Data
+		}
+		s[i] = float16.Frombits(uint16(v))
+	}
+	return s, nil
+}
+
+// An OverflowError reports finite float32 values that overflowed to infinity
+// when converted to Float16.
+type OverflowError struct {
+	Count int     // number of values that overflowed
+	Index int     // index of the first one
+	Value float32 // first value that overflowed
+}
+
+func (e *OverflowError) Error() string {
+	return fmt.Sprintf("float16/onnx: %d values overflow float16, first %v at index %d", e.Count, e.Value, e.Index)
+}
+
+// FromFloat32s converts the values of src to Float16 like float16.FromFloat32s.
+// If finite values overflowed to infinity, it returns all the converted values
+// and an *OverflowError.  Infinities and NaNs in src aren't overflows.
+func FromFloat32s(src []float32) ([]float16.Float16, error) {
+	s := make([]float16.Float16, len(src))
+	float16.FromFloat32s(s, src)
+	var e *OverflowError
+	for i, f := range s {
+		if f.IsInf(0) && !math.IsInf(float64(src[i]), 0) {
+			if e == nil {
+				e = &OverflowError{Index: i, Value: src[i]}
+			}
+			e.Count++
+		}
+	}
+	if e != nil {
+		return s, e
+	}
+	return s, nil
+}
+
+// An Initializer holds the TensorProto fields of a FLOAT16 initializer.
+type Initializer struct {
+	Name     string
+	Dims     []int64
+	DataType int32 // always DataTypeFloat16
+	RawData  []byte
+}
+
+// NewInitializer converts the float32 tensor src with the specified dims to a
+// FLOAT16 initializer stored in raw_data.  It returns ErrDims if a dim is
+// negative or the product of dims isn't len(src).  Like FromFloat32s, it
+// returns the initializer and an *OverflowError if values overflowed.
+func NewInitializer(name string, dims []int64, src []float32) (*Initializer, error) {
+	n := int64(1)
+	for _, d := range dims {
+		if d < 0 || d != 0 && n > math.MaxInt64/d {
+			return nil, ErrDims
+		}
+		n *= d
+	}
+	if n != int64(len(src)) {
+		return nil, ErrDims
+	}
+	s, err := FromFloat32s(src)
+	t := &Initializer{
+		Name:     name,
+		Dims:     append([]int64(nil), dims...),
+		DataType: DataTypeFloat16,
+		RawData:  RawData(s),
+	}
+	return t, err
+}
@@ -0,0 +1,103 @@
+// Copyright 2019 Montgomery Edwards⁴⁴⁸ and Faye Amacker
+
+package onnx_test
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/x448/float16"
+	"github.com/x448/float16/onnx"
+)
+
+var (
+	values = []float16.Float16{0x3c00, 0xbc00, 0x7e01, 0x8001, 0xfbff, 0x0000}
+	raw    = []byte{0x00, 0x3c, 0x00, 0xbc, 0x01, 0x7e, 0x01, 0x80, 0xff, 0xfb, 0x00, 0x00}
+	int32s = []int32{0x3c00, 0xbc00, 0x7e01, 0x8001, 0xfbff, 0x0000}
+)
+
+func TestRawData(t *testing.T) {
+	if got := onnx.RawData(values); !bytes.Equal(got, raw) {
+		t.Errorf("RawData = % x, wanted % x", got, raw)
+	}
+	if got, err := onnx.FromRawData(raw); err != nil || !reflect.DeepEqual(got, values) {
+		t.Errorf("FromRawData = %v, %v, wanted %v, nil", got, err, values)
+	}
+	if got, err := onnx.FromRawData(raw[:3]); got != nil || err != onnx.ErrRawDataLength {
+		t.Errorf("FromRawData(3 bytes) = %v, %v, wanted nil, %v", got, err, onnx.ErrRawDataLength)
+	}
+}
+
+func TestInt32Data(t *testing.T) {
+	if got := onnx.Int32Data(values); !reflect.DeepEqual(got, int32s) {
+		t.Errorf("Int32Data = %#x, wanted %#x", got, int32s)
+	}
+	if got, err := onnx.FromInt32Data(int32s); err != nil || !reflect.DeepEqual(got, values) {
+		t.Errorf("FromInt32Data = %v, %v, wanted %v, nil", got, err, values)
+	}
+	for _, d := range [][]int32{{0x3c00, -0x4400}, {0x10000}, {math.MaxInt32}} {
+		if got, err := onnx.FromInt32Data(d); got != nil || err != onnx.ErrInt32Data {
+			t.Errorf("FromInt32Data(%#x) = %v, %v, wanted nil, %v", d, got, err, onnx.ErrInt32Data)
+		}
+	}
+}
+
+func TestFromFloat32s(t *testing.T) {
+	inf := float32(math.Inf(1))
+	src := []float32{1, 65504, inf, float32(math.NaN()), -inf, 0x1p-25}
+	want := []float16.Float16{0x3c00, 0x7bff, 0x7c00, 0x7e00, 0xfc00, 0x0000}
+	if got, err := onnx.FromFloat32s(src); err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("FromFloat32s = %v, %v, wanted %v, nil", got, err, want)
+	}
+
+	// 65520 rounds up to infinity.
+	src = []float32{1, -65520, 2, 1e10, math.MaxFloat32}
+	want = []float16.Float16{0x3c00, 0xfc00, 0x4000, 0x7c00, 0x7c00}
+	got, err := onnx.FromFloat32s(src)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FromFloat32s = %v, wanted %v", got, want)
+	}
+	wantErr := &onnx.OverflowError{Count: 3, Index: 1, Value: -65520}
+	if !reflect.DeepEqual(err, wantErr) {
+		t.Fatalf("FromFloat32s error = %#v, wanted %#v", err, wantErr)
+	}
+	if msg := "float16/onnx: 3 values overflow float16, first -65520 at index 1"; err.Error() != msg {
+		t.Errorf("Error() = %q, wanted %q", err.Error(), msg)
+	}
+}
+
+func TestNewInitializer(t *testing.T) {
+	dims := []int64{2, 3}
+	src := []float32{1, -1, float32(math.NaN()), -0x1p-24, -65504, 0}
+	tp, err := onnx.NewInitializer("w", dims, src)
+	if err != nil {
+		t.Fatalf("NewInitializer: %v", err)
+	}
+	want := &onnx.Initializer{Name: "w", Dims: []int64{2, 3}, DataType: onnx.DataTypeFloat16, RawData: []byte{
+		0x00, 0x3c, 0x00, 0xbc, 0x00, 0x7e, 0x01, 0x80, 0xff, 0xfb, 0x00, 0x00,
+	}}
+	if !reflect.DeepEqual(tp, want) {
+		t.Errorf("NewInitializer = %+v, wanted %+v", tp, want)
+	}
+	dims[0] = 6
+	if tp.Dims[0] != 2 {
+		t.Errorf("NewInitializer didn't copy dims")
+	}
+
+	// The initializer is returned with overflow errors.
+	tp, err = onnx.NewInitializer("s", nil, []float32{1e5})
+	if _, ok := err.(*onnx.OverflowError); !ok || tp == nil || !bytes.Equal(tp.RawData, []byte{0x00, 0x7c}) {
+		t.Errorf("NewInitializer(1e5) = %+v, %v, wanted infinity and *OverflowError", tp, err)
+	}
+
+	for _, dims := range [][]int64{{2, 2}, {-2, -3}, {1 << 40, 1 << 40}, {0}} {
+		if tp, err := onnx.NewInitializer("x", dims, src); tp != nil || err != onnx.ErrDims {
+			t.Errorf("NewInitializer(dims %v) = %v, %v, wanted nil, %v", dims, tp, err, onnx.ErrDims)
+		}
+	}
+	if _, err := onnx.NewInitializer("empty", []int64{3, 0}, nil); err != nil {
+		t.Errorf("NewInitializer(dims [3 0]) = %v, wanted nil", err)
+	}
+}
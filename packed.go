@@ -0,0 +1,167 @@
+// Copyright 2019 Montgomery Edwards⁴⁴⁸ and Faye Amacker
+
+package float16
+
+import "math"
+
+// R11G11B10F is three unsigned floats packed into 32 bits, like
+// DXGI_FORMAT_R11G11B10_FLOAT and VK_FORMAT_B10G11R11_UFLOAT_PACK32.  Red is
+// in bits 0-10, green in bits 11-21 and blue in bits 22-31.  Red and green
+// are 11-bit floats with a 6-bit significand, and blue is a 10-bit float with
+// a 5-bit significand.  Both have no sign bit and the 5-bit exponent of
+// Float16, so they are Float16 with low significand bits dropped.
+type R11G11B10F uint32
+
+// PackR11G11B10F returns r, g and b rounded to nearest with ties to even.
+// As D3D and Vulkan specify, negative values and -Inf convert to 0, finite
+// values too large for the format convert to the largest finite value
+// (65024 for red and green, 64512 for blue), +Inf stays +Inf, and NaN stays
+// NaN.
+func PackR11G11B10F(r, g, b float32) R11G11B10F {
+	return R11G11B10F(f32bitsToUFloatBits(math.Float32bits(r), 6) |
+		f32bitsToUFloatBits(math.Float32bits(g), 6)<<11 |
+		f32bitsToUFloatBits(math.Float32bits(b), 5)<<22)
+}
+
+// PackR11G11B10F16 is like PackR11G11B10F with Float16 values.
+func PackR11G11B10F16(r, g, b Float16) R11G11B10F {
+	return PackR11G11B10F(r.Float32(), g.Float32(), b.Float32())
+}
+
+// Float16s returns the red, green and blue values of p.  The conversion is
+// exact.
+func (p R11G11B10F) Float16s() (r, g, b Float16) {
+	return Float16(p&0x7ff) << 4, Float16(p>>11&0x7ff) << 4, Float16(p>>22) << 5
+}
+
+// Float32s returns the red, green and blue values of p.  The conversion is
+// exact.
+func (p R11G11B10F) Float32s() (r, g, b float32) {
+	r16, g16, b16 := p.Float16s()
+	return r16.Float32(), g16.Float32(), b16.Float32()
+}
+
+// f32bitsToUFloatBits returns the bits of an unsigned float with a 5-bit
+// exponent and an m-bit significand converted from the specified float32
+// bits.  It rounds like f32bitsToF16bits and clamps like PackR11G11B10F.
+func f32bitsToUFloatBits(u32 uint32, m uint32) uint32 {
+	a := u32 & 0x7fffffff // exponent and significand
+	s := 23 - m           // significand bits dropped from normal numbers
+	inf := uint32(0x1f) << m
+
+	switch {
+	case a > 0x7f800000:
+		// NaN of either sign is quieted and keeps the top of its payload.
+		return inf | 1<<(m-1) | a>>s&(1<<m-1)
+	case u32 != a:
+		return 0 // negative numbers, -0 and -Inf
+	case a == 0x7f800000:
+		return inf
+	case a < 0x38800000:
+		// Subnormal numbers are rounded by the FPU: adding 2**(9-m) leaves
+		// f * 2**(14+m) rounded in the low bits.  Rounding can carry into
+		// the exponent.
+		c := math.Float32frombits((136 - m) << 23)
+		return math.Float32bits(math.Float32frombits(a)+c) - math.Float32bits(c)
+	}
+
+	// Normal numbers rebias the exponent by 127-15 and round at bit s by
+	// adding half minus 1 plus the lowest kept bit.  Finite numbers that
+	// round to infinity or beyond are clamped.
+	r := (a - 0x38000000 + 1<<(s-1) - 1 + a>>s&1) >> s
+	if r >= inf {
+		r = inf - 1
+	}
+	return r
+}
+
+// RGB9E5 is three unsigned floats with 9-bit significands and a shared 5-bit
+// exponent packed into 32 bits, like DXGI_FORMAT_R9G9B9E5_SHAREDEXP and
+// VK_FORMAT_E5B9G9R9_UFLOAT_PACK32.  Red is in bits 0-8, green in bits 9-17,
+// blue in bits 18-26 and the exponent in bits 27-31.  Each value is its
+// significand times 2**(exponent-24), without an implicit leading bit.
+type RGB9E5 uint32
+
+// maxRGB9E5Bits is the float32 bits of the largest RGB9E5 value, 65408.
+const maxRGB9E5Bits = 0x477f8000
+
+// PackRGB9E5 returns r, g and b packed as the shared exponent formulas of D3D
+// and Vulkan specify.  Values are clamped to [0, 65408], with NaN converting
+// to 0.  The shared exponent is chosen for the largest value, and each value
+// is rounded to nearest with ties away from zero, so values much smaller than
+// the largest one can convert to 0.
+func PackRGB9E5(r, g, b float32) RGB9E5 {
+	rb, gb, bb := clampRGB9E5(r), clampRGB9E5(g), clampRGB9E5(b)
+
+	// The order of bits of non-negative floats is the order of their values.
+	maxBits := rb
+	if gb > maxBits {
+		maxBits = gb
+	}
+	if bb > maxBits {
+		maxBits = bb
+	}
+
+	// exp = max(-16, floor(log2(max))) + 16, incremented if the largest
+	// value rounds up to 2**9.
+	exp := uint32(0)
+	if e := maxBits >> 23; e > 127-16 {
+		exp = e - (127 - 16)
+	}
+	if rgb9e5Significand(maxBits, exp) == 1<<9 {
+		exp++
+	}
+	return RGB9E5(rgb9e5Significand(rb, exp) |
+		rgb9e5Significand(gb, exp)<<9 |
+		rgb9e5Significand(bb, exp)<<18 |
+		exp<<27)
+}
+
+// PackRGB9E5F16 is like PackRGB9E5 with Float16 values.
+func PackRGB9E5F16(r, g, b Float16) RGB9E5 {
+	return PackRGB9E5(r.Float32(), g.Float32(), b.Float32())
+}
+
+// clampRGB9E5 returns the float32 bits of f clamped to [0, 65408], with NaN
+// and -0 converting to 0.
+func clampRGB9E5(f float32) uint32 {
+	u := math.Float32bits(f)
+	switch {
+	case u > 0x7f800000:
+		return 0 // NaN and negative numbers
+	case u > maxRGB9E5Bits:
+		return maxRGB9E5Bits
+	}
+	return u
+}
+
+// rgb9e5Significand returns floor(f / 2**(exp-24) + 0.5) for the non-negative
+// float32 bits f.
+func rgb9e5Significand(f uint32, exp uint32) uint32 {
+	// f is sig * 2**(e-150).
+	e, sig := f>>23, f&0x7fffff
+	if e == 0 {
+		e = 1 // subnormal
+	} else {
+		sig |= 0x800000
+	}
+
+	// s is at least 15, because exp is at least e-111.  Shifts of 32 or
+	// more give 0, and sig is small enough that adding half can't overflow.
+	s := exp + 126 - e
+	return (sig + 1<<(s-1)) >> s
+}
+
+// Float16s returns the red, green and blue values of p.  The conversion is
+// exact.
+func (p RGB9E5) Float16s() (r, g, b Float16) {
+	r32, g32, b32 := p.Float32s()
+	return Fromfloat32(r32), Fromfloat32(g32), Fromfloat32(b32)
+}
+
+// Float32s returns the red, green and blue values of p.  The conversion is
+// exact.
+func (p RGB9E5) Float32s() (r, g, b float32) {
+	scale := math.Float32frombits((uint32(p>>27) + 127 - 24) << 23) // 2**(exp-24)
+	return float32(p&0x1ff) * scale, float32(p>>9&0x1ff) * scale, float32(p>>18&0x1ff) * scale
+}
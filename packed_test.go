@@ -0,0 +1,231 @@
+// Copyright 2019 Montgomery Edwards⁴⁴⁸ and Faye Amacker
+
+package float16_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/x448/float16"
+)
+
+// ufloat returns the value of the bits of an unsigned float with a 5-bit
+// exponent and an m-bit significand, computed from the format's definition.
+func ufloat(bits uint32, m uint) float64 {
+	e, sig := int(bits>>m), float64(bits&(1<<m-1))
+	switch e {
+	case 0:
+		return math.Ldexp(sig, -14-int(m))
+	case 31:
+		if sig != 0 {
+			return math.NaN()
+		}
+		return math.Inf(1)
+	}
+	return math.Ldexp(sig+float64(uint(1)<<m), e-15-int(m))
+}
+
+// checkUFloat checks that bits is f converted to an unsigned float with an
+// m-bit significand, rounded to nearest with ties to even and clamped as D3D
+// and Vulkan specify.
+func checkUFloat(t *testing.T, f float32, bits uint32, m uint) {
+	inf := uint32(31) << m
+	x := float64(f)
+	ok := true
+	switch {
+	case math.IsNaN(x):
+		ok = bits > inf && bits < inf<<1
+	case x < 0 || math.Signbit(x):
+		ok = bits == 0
+	case math.IsInf(x, 1):
+		ok = bits == inf
+	case x >= ufloat(inf-1, m):
+		ok = bits == inf-1
+	case bits >= inf:
+		ok = false
+	default:
+		// bits is at least as close to x as its neighbors, and even on ties.
+		d := math.Abs(x - ufloat(bits, m))
+		if bits > 0 {
+			if dn := math.Abs(x - ufloat(bits-1, m)); d > dn || d == dn && bits&1 != 0 {
+				ok = false
+			}
+		}
+		if dn := math.Abs(x - ufloat(bits+1, m)); bits+1 < inf && (d > dn || d == dn && bits&1 != 0) {
+			ok = false
+		}
+	}
+	if !ok {
+		t.Fatalf("%v (0x%08x) converted to %d-bit significand = 0x%03x (%v)", f, math.Float32bits(f), m, bits, ufloat(bits, m))
+	}
+}
+
+// refRGB9E5 returns r, g and b packed with the D3D and Vulkan formulas.
+func refRGB9E5(r, g, b float32) float16.RGB9E5 {
+	clamp := func(f float32) float64 {
+		if !(f > 0) {
+			return 0
+		}
+		return math.Min(float64(f), 65408)
+	}
+	rc, gc, bc := clamp(r), clamp(g), clamp(b)
+	maxc := math.Max(rc, math.Max(gc, bc))
+	exp := 0
+	if maxc > 0 {
+		_, e := math.Frexp(maxc) // maxc is in [2**(e-1), 2**e)
+		if e-1 > -16 {
+			exp = e - 1 + 16
+		}
+	}
+	if math.Floor(maxc/math.Ldexp(1, exp-24)+0.5) == 512 {
+		exp++
+	}
+	sig := func(v float64) uint32 {
+		return uint32(math.Floor(v/math.Ldexp(1, exp-24) + 0.5))
+	}
+	return float16.RGB9E5(sig(rc) | sig(gc)<<9 | sig(bc)<<18 | uint32(exp)<<27)
+}
+
+func checkPacked(t *testing.T, f float32) {
+	p := float16.PackR11G11B10F(f, f, f)
+	r, g, b := uint32(p&0x7ff), uint32(p>>11&0x7ff), uint32(p>>22)
+	if r != g {
+		t.Fatalf("PackR11G11B10F(%v, %v, %v) = 0x%08x, wanted equal red and green", f, f, f, uint32(p))
+	}
+	checkUFloat(t, f, r, 6)
+	checkUFloat(t, f, b, 5)
+
+	if got, want := float16.PackRGB9E5(f, 0, 0), refRGB9E5(f, 0, 0); got != want {
+		t.Fatalf("PackRGB9E5(%v (0x%08x), 0, 0) = 0x%08x, wanted 0x%08x", f, math.Float32bits(f), uint32(got), uint32(want))
+	}
+}
+
+// checkPackedBits checks the float32 with bits u like checkPacked, or
+// quickly if it converts to 0 or the largest values.
+func checkPackedBits(t *testing.T, u uint32) {
+	const maxR11G11B10F, maxRGB9E5 = 0x7bf | 0x7bf<<11 | 0x3df<<22, 0x1ff | 0x1ff<<9 | 0x1ff<<18 | 31<<27
+	f, a := math.Float32frombits(u), u&0x7fffffff
+	if a >= 0x7f800000 || a >= 0x32800000 && a < 0x48000000 && u == a {
+		checkPacked(t, f) // NaN, infinities and [2**-26, 2**17)
+		return
+	}
+	p, q := uint32(maxR11G11B10F), uint32(maxRGB9E5)
+	if u != a || a < 0x32800000 {
+		p, q = 0, 0
+	}
+	if got, got9 := float16.PackR11G11B10F(f, f, f), float16.PackRGB9E5(f, f, f); uint32(got) != p || uint32(got9) != q {
+		t.Fatalf("%v (0x%08x) packed = 0x%08x and 0x%08x, wanted 0x%08x and 0x%08x", f, u, uint32(got), uint32(got9), p, q)
+	}
+}
+
+// Test all float32 values that convert to something other than 0 or the
+// largest values, plus NaNs and infinities, with both packed formats.  The
+// rest are sampled, like all values in short mode.
+func TestAllPackFloat32(t *testing.T) {
+	for u := uint64(0); u <= math.MaxUint32; u += 4099 {
+		checkPackedBits(t, uint32(u))
+	}
+	for _, f := range []float32{0, 65024, 65280, 65281, 64512, 64768, 65408, 65504, 65535, 1e-30, 1.0 / (1 << 20), 1.5 / (1 << 20)} {
+		checkPacked(t, f)
+	}
+	if testing.Short() {
+		return
+	}
+	for _, r := range [][2]uint64{{0x32800000, 0x48000000}, {0x7f800000, 0x80000000}, {0xff800000, 1 << 32}} {
+		for u := r[0]; u < r[1]; u++ {
+			checkPackedBits(t, uint32(u))
+		}
+	}
+}
+
+func TestPackFloat16(t *testing.T) {
+	for i := 0; i < 1<<16; i++ {
+		h := float16.Frombits(uint16(i))
+		f := h.Float32()
+		if got, want := float16.PackR11G11B10F16(h, h, h), float16.PackR11G11B10F(f, f, f); got != want {
+			t.Fatalf("PackR11G11B10F16(0x%04x) = 0x%08x, wanted 0x%08x", i, uint32(got), uint32(want))
+		}
+		if got, want := float16.PackRGB9E5F16(h, 0, h), float16.PackRGB9E5(f, 0, f); got != want {
+			t.Fatalf("PackRGB9E5F16(0x%04x) = 0x%08x, wanted 0x%08x", i, uint32(got), uint32(want))
+		}
+	}
+}
+
+func TestPackExamples(t *testing.T) {
+	if got, want := float16.PackR11G11B10F(1, 0.5, 1e6), float16.R11G11B10F(0x3c0|0x380<<11|0x3df<<22); got != want {
+		t.Errorf("PackR11G11B10F(1, 0.5, 1e6) = 0x%08x, wanted 0x%08x", uint32(got), uint32(want))
+	}
+	if got, want := float16.PackRGB9E5(1, 1, 1), float16.RGB9E5(256|256<<9|256<<18|16<<27); got != want {
+		t.Errorf("PackRGB9E5(1, 1, 1) = 0x%08x, wanted 0x%08x", uint32(got), uint32(want))
+	}
+	if got, want := float16.PackRGB9E5(float32(math.Inf(1)), float32(math.NaN()), -1), float16.RGB9E5(511|31<<27); got != want {
+		t.Errorf("PackRGB9E5(+Inf, NaN, -1) = 0x%08x, wanted 0x%08x", uint32(got), uint32(want))
+	}
+}
+
+func TestPackRGB9E5(t *testing.T) {
+	rng := rand.New(rand.NewSource(49))
+	for i := 0; i < 1<<18; i++ {
+		// Values with nearby exponents, from below the smallest shared
+		// exponent to above the largest value.
+		exp := rng.Intn(44) - 27
+		var v [3]float32
+		for j := range v {
+			v[j] = float32(math.Ldexp(rng.Float64(), exp-rng.Intn(12)))
+		}
+		if got, want := float16.PackRGB9E5(v[0], v[1], v[2]), refRGB9E5(v[0], v[1], v[2]); got != want {
+			t.Fatalf("PackRGB9E5(%v) = 0x%08x, wanted 0x%08x", v, uint32(got), uint32(want))
+		}
+	}
+}
+
+func TestUnpackR11G11B10F(t *testing.T) {
+	for bits := uint32(0); bits < 1<<11; bits++ {
+		// Red, green and blue, with blue having one fewer bit.
+		for i, p := range []float16.R11G11B10F{
+			float16.R11G11B10F(bits),
+			float16.R11G11B10F(bits << 11),
+			float16.R11G11B10F(bits&0x3ff) << 22,
+		} {
+			m := [3]uint{6, 6, 5}[i]
+			want := [3]float64{}
+			want[i] = ufloat(bits&(1<<(m+5)-1), m)
+			r, g, b := p.Float32s()
+			r16, g16, b16 := p.Float16s()
+			got, got16 := [3]float32{r, g, b}, [3]float16.Float16{r16, g16, b16}
+			same := func(f float32, v float64) bool {
+				return float64(f) == v || f != f && math.IsNaN(v)
+			}
+			for j := range got {
+				if !same(got[j], want[j]) || !same(got16[j].Float32(), want[j]) {
+					t.Fatalf("0x%08x: Float32s = %v and Float16s = %v, wanted %v", uint32(p), got, got16, want)
+				}
+			}
+
+			// Values other than NaN round trip.
+			if !math.IsNaN(want[i]) {
+				if q := float16.PackR11G11B10F(r, g, b); q != p {
+					t.Fatalf("PackR11G11B10F(%v, %v, %v) = 0x%08x, wanted 0x%08x", r, g, b, uint32(q), uint32(p))
+				}
+			}
+		}
+	}
+}
+
+func TestUnpackRGB9E5(t *testing.T) {
+	for exp := uint32(0); exp < 32; exp++ {
+		for sig := uint32(0); sig < 512; sig++ {
+			p := float16.RGB9E5(sig | (511-sig)<<9 | sig/2<<18 | exp<<27)
+			r, g, b := p.Float32s()
+			scale := math.Ldexp(1, int(exp)-24)
+			if float64(r) != float64(sig)*scale || float64(g) != float64(511-sig)*scale || float64(b) != float64(sig/2)*scale {
+				t.Fatalf("0x%08x.Float32s() = %v, %v, %v", uint32(p), r, g, b)
+			}
+			r16, g16, b16 := p.Float16s()
+			if r16.Float32() != r || g16.Float32() != g || b16.Float32() != b {
+				t.Fatalf("0x%08x.Float16s() = %v, %v, %v, wanted %v, %v, %v", uint32(p), r16, g16, b16, r, g, b)
+			}
+		}
+	}
+}
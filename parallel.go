@@ -0,0 +1,108 @@
+// Copyright 2019 Montgomery Edwards⁴⁴⁸ and Faye Amacker
+
+package float16
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// parallelChunk is the number of values each goroutine converts at a time.
+// 64Ki values keep a chunk of src and dst (384 KiB for float32) within L2 on
+// most CPUs, and take long enough that checking ctx between chunks is cheap.
+const parallelChunk = 1 << 16
+
+// FromFloat32sParallel is like FromFloat32s, but splits the values into chunks
+// converted by up to GOMAXPROCS goroutines.  Results are identical to
+// FromFloat32s.
+//
+// If progress isn't nil, it's called after each chunk with the number of
+// values converted so far.  Calls are made one at a time from the converting
+// goroutines, so progress should return quickly.
+//
+// If ctx is done before all chunks are converted, FromFloat32sParallel stops
+// starting chunks, waits for the ones being converted, and returns ctx.Err()
+// with the number of values at the start of dst that are converted.  Chunks
+// are converted out of order, so values after those may be converted too.
+func FromFloat32sParallel(ctx context.Context, dst []Float16, src []float32, progress func(done int)) (int, error) {
+	n := len(src)
+	if len(dst) < n {
+		n = len(dst)
+	}
+	return parallel(ctx, n, progress, func(lo, hi int) {
+		kernel.f32sToF16s(dst[lo:hi], src[lo:hi])
+	})
+}
+
+// ToFloat32sParallel is like ToFloat32s, but splits the values into chunks
+// converted by up to GOMAXPROCS goroutines.  Results are identical to
+// ToFloat32s.  ctx and progress work like they do for FromFloat32sParallel.
+func ToFloat32sParallel(ctx context.Context, dst []float32, src []Float16, progress func(done int)) (int, error) {
+	n := len(src)
+	if len(dst) < n {
+		n = len(dst)
+	}
+	return parallel(ctx, n, progress, func(lo, hi int) {
+		kernel.f16sToF32s(dst[lo:hi], src[lo:hi])
+	})
+}
+
+// parallel calls convert for each chunk of [0, n) from up to GOMAXPROCS
+// goroutines and returns when all chunks are converted or ctx is done.  It
+// returns the end of the longest run of converted chunks starting at 0.
+func parallel(ctx context.Context, n int, progress func(done int), convert func(lo, hi int)) (int, error) {
+	chunks := (n + parallelChunk - 1) / parallelChunk
+	workers := runtime.GOMAXPROCS(0)
+	if workers > chunks {
+		workers = chunks
+	}
+
+	var (
+		next      int64 // index of the next chunk to convert
+		mu        sync.Mutex
+		done      int                    // values converted, guarded by mu
+		converted = make([]bool, chunks) // chunks converted, guarded by mu
+		wg        sync.WaitGroup
+	)
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				c := int(atomic.AddInt64(&next, 1) - 1)
+				if c >= chunks {
+					return
+				}
+				lo := c * parallelChunk
+				hi := lo + parallelChunk
+				if hi > n {
+					hi = n
+				}
+				convert(lo, hi)
+
+				mu.Lock()
+				done += hi - lo
+				converted[c] = true
+				if progress != nil {
+					progress(done)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if done < n {
+		prefix := 0
+		for _, ok := range converted {
+			if !ok {
+				break
+			}
+			prefix += parallelChunk
+		}
+		return prefix, ctx.Err()
+	}
+	return n, nil
+}
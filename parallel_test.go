@@ -0,0 +1,116 @@
+// Copyright 2019 Montgomery Edwards⁴⁴⁸ and Faye Amacker
+
+package float16_test
+
+import (
+	"context"
+	"math"
+	"runtime"
+	"testing"
+
+	"github.com/x448/float16"
+)
+
+// parallelLen is several chunks plus an odd tail.
+const parallelLen = 5<<16 + 7
+
+func TestFromFloat32sParallel(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+
+	src := make([]float32, parallelLen)
+	for i := range src {
+		src[i] = math.Float32frombits(uint32(i) * 0x9e3779b1)
+	}
+	want := make([]float16.Float16, len(src))
+	float16.FromFloat32s(want, src)
+
+	var calls, last int
+	dst := make([]float16.Float16, len(src)+1)
+	n, err := float16.FromFloat32sParallel(context.Background(), dst, src, func(done int) {
+		if done <= last {
+			t.Errorf("progress(%d) after progress(%d)", done, last)
+		}
+		calls, last = calls+1, done
+	})
+	if n != len(src) || err != nil {
+		t.Fatalf("FromFloat32sParallel = %d, %v, wanted %d, nil", n, err, len(src))
+	}
+	if calls != 6 || last != len(src) {
+		t.Errorf("progress called %d times, last with %d, wanted 6 times, last with %d", calls, last, len(src))
+	}
+	for i := range want {
+		if dst[i] != want[i] {
+			t.Fatalf("FromFloat32sParallel f32bits=0x%08x = 0x%04x, wanted 0x%04x", math.Float32bits(src[i]), dst[i].Bits(), want[i].Bits())
+		}
+	}
+
+	if n, err := float16.FromFloat32sParallel(context.Background(), dst[:3], src, nil); n != 3 || err != nil {
+		t.Errorf("FromFloat32sParallel = %d, %v, wanted 3, nil", n, err)
+	}
+}
+
+func TestToFloat32sParallel(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+
+	src := make([]float16.Float16, parallelLen)
+	for i := range src {
+		src[i] = float16.Frombits(uint16(i))
+	}
+	want := make([]float32, len(src))
+	float16.ToFloat32s(want, src)
+
+	dst := make([]float32, len(src)+1)
+	n, err := float16.ToFloat32sParallel(context.Background(), dst, src, nil)
+	if n != len(src) || err != nil {
+		t.Fatalf("ToFloat32sParallel = %d, %v, wanted %d, nil", n, err, len(src))
+	}
+	for i := range want {
+		if math.Float32bits(dst[i]) != math.Float32bits(want[i]) {
+			t.Fatalf("ToFloat32sParallel 0x%04x = 0x%08x, wanted 0x%08x", src[i].Bits(), math.Float32bits(dst[i]), math.Float32bits(want[i]))
+		}
+	}
+
+	if n, err := float16.ToFloat32sParallel(context.Background(), nil, src, nil); n != 0 || err != nil {
+		t.Errorf("ToFloat32sParallel = %d, %v, wanted 0, nil", n, err)
+	}
+}
+
+func TestParallelCanceled(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+
+	src := make([]float16.Float16, parallelLen)
+	for i := range src {
+		src[i] = 0x3c00
+	}
+	dst := make([]float32, len(src))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if n, err := float16.ToFloat32sParallel(ctx, dst, src, func(int) {
+		t.Errorf("progress called after cancel")
+	}); n != 0 || err != context.Canceled {
+		t.Errorf("ToFloat32sParallel with canceled ctx returned %d, %v, wanted 0, %v", n, err, context.Canceled)
+	}
+
+	// Cancel from the first progress call, so at most the chunks already
+	// started get converted.  The count is of the converted values at the
+	// start of dst.
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	var last int
+	n, err := float16.ToFloat32sParallel(ctx, dst, src, func(done int) {
+		cancel()
+		last = done
+	})
+	if err != context.Canceled {
+		t.Errorf("ToFloat32sParallel canceled by progress returned %v, wanted %v", err, context.Canceled)
+	}
+	if last >= len(src) || n > last {
+		t.Errorf("ToFloat32sParallel converted %d values and returned %d after cancel, wanted fewer than %d", last, n, len(src))
+	}
+	for i, f := range dst[:n] {
+		if f != 1 {
+			t.Fatalf("ToFloat32sParallel returned %d, but dst[%d] = %v isn't converted", n, i, f)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+// Copyright 2019 Montgomery Edwards⁴⁴⁸ and Faye Amacker
+
+// Package parquet encodes and decodes Parquet columns of the FLOAT16 logical
+// type and computes their statistics.
+//
+// FLOAT16 columns have the physical type FIXED_LEN_BYTE_ARRAY with a length
+// of 2, holding the bits of each value in little-endian order.  See
+// https://github.com/apache/parquet-format/blob/master/LogicalTypes.md.
+package parquet
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/x448/float16"
+)
+
+// ErrLength indicates encoded data whose length isn't a multiple of 2.
+var ErrLength = errors.New("float16/parquet: data length isn't a multiple of 2")
+
+// AppendPlain appends the PLAIN encoding of s to b and returns the extended
+// buffer.  PLAIN encodes each value as its 2 little-endian bytes.
+func AppendPlain(b []byte, s []float16.Float16) []byte {
+	n := len(b)
+	b = grow(b, 2*len(s))
+	float16.EncodeSlice(b[n:], s, binary.LittleEndian)
+	return b
+}
+
+// DecodePlain decodes PLAIN encoded values.
+func DecodePlain(b []byte) ([]float16.Float16, error) {
+	if len(b)%2 != 0 {
+		return nil, ErrLength
+	}
+	s := make([]float16.Float16, len(b)/2)
+	float16.DecodeSlice(s, b, binary.LittleEndian)
+	return s, nil
+}
+
+// AppendByteStreamSplit appends the BYTE_STREAM_SPLIT encoding of s to b and
+// returns the extended buffer.  BYTE_STREAM_SPLIT stores the low bytes of all
+// values followed by their high bytes, which often compresses better.
+func AppendByteStreamSplit(b []byte, s []float16.Float16) []byte {
+	n := len(b)
+	b = grow(b, 2*len(s))
+	lo, hi := b[n:n+len(s)], b[n+len(s):]
+	for i, f := range s {
+		lo[i] = byte(f)
+		hi[i] = byte(f >> 8)
+	}
+	return b
+}
+
+// DecodeByteStreamSplit decodes BYTE_STREAM_SPLIT encoded values.
+func DecodeByteStreamSplit(b []byte) ([]float16.Float16, error) {
+	if len(b)%2 != 0 {
+		return nil, ErrLength
+	}
+	s := make([]float16.Float16, len(b)/2)
+	lo, hi := b[:len(s)], b[len(s):]
+	for i := range s {
+		s[i] = float16.Frombits(uint16(lo[i]) | uint16(hi[i])<<8)
+	}
+	return s, nil
+}
+
+// grow extends b by n bytes.  Like append, it grows the capacity by a factor,
+// so repeated calls take amortized linear time.
+func grow(b []byte, n int) []byte {
+	if cap(b)-len(b) < n {
+		return append(b, make([]byte, n)...)
+	}
+	return b[:len(b)+n]
+}
+
+// Statistics computes the statistics of a FLOAT16 column chunk or page.  The
+// zero value is ready to use.
+//
+// Min and max follow the Parquet rules for floating-point columns: values are
+// compared as IEEE 754 numbers, so -0 equals +0; NaNs are counted but never
+// become the min or max; and a zero min is written as -0 and a zero max as
+// +0, so readers don't wrongly skip data containing the other zero.
+type Statistics struct {
+	nulls    int64
+	nans     int64
+	numbers  int64 // values that aren't null or NaN
+	min, max float32
+}
+
+// Update adds the non-null values in s.
+func (st *Statistics) Update(s []float16.Float16) {
+	for _, f := range s {
+		if f.IsNaN() {
+			st.nans++
+			continue
+		}
+		v := f.Float32()
+		if st.numbers == 0 {
+			st.min, st.max = v, v
+		} else if v < st.min {
+			st.min = v
+		} else if v > st.max {
+			st.max = v
+		}
+		st.numbers++
+	}
+}
+
+// UpdateNulls adds n null values.
+func (st *Statistics) UpdateNulls(n int64) {
+	st.nulls += n
+}
+
+// Merge adds the values counted by o, such as the statistics of another page.
+func (st *Statistics) Merge(o *Statistics) {
+	if o.HasMinMax() {
+		if !st.HasMinMax() {
+			st.min, st.max = o.min, o.max
+		} else {
+			if o.min < st.min {
+				st.min = o.min
+			}
+			if o.max > st.max {
+				st.max = o.max
+			}
+		}
+	}
+	st.nulls += o.nulls
+	st.nans += o.nans
+	st.numbers += o.numbers
+}
+
+// NullCount returns the number of null values.
+func (st *Statistics) NullCount() int64 { return st.nulls }
+
+// NaNCount returns the number of NaN values.
+func (st *Statistics) NaNCount() int64 { return st.nans }
+
+// HasMinMax reports whether the values include any that aren't NaN or null,
+// so Min and Max are valid.
+func (st *Statistics) HasMinMax() bool { return st.numbers > 0 }
+
+// Min returns the smallest value, or -0 if it's zero.  It returns NaN if
+// HasMinMax is false.
+func (st *Statistics) Min() float16.Float16 {
+	if !st.HasMinMax() {
+		return float16.NaN()
+	}
+	if st.min == 0 {
+		return float16.Frombits(0x8000)
+	}
+	return float16.Fromfloat32(st.min)
+}
+
+// Max returns the largest value, or +0 if it's zero.  It returns NaN if
+// HasMinMax is false.
+func (st *Statistics) Max() float16.Float16 {
+	if !st.HasMinMax() {
+		return float16.NaN()
+	}
+	if st.max == 0 {
+		return 0
+	}
+	return float16.Fromfloat32(st.max)
+}
+
+// MinValue returns Min encoded for the min_value field of Parquet
+// statistics, or nil if HasMinMax is false.
+func (st *Statistics) MinValue() []byte {
+	if !st.HasMinMax() {
+		return nil
+	}
+	return AppendPlain(nil, []float16.Float16{st.Min()})
+}
+
+// MaxValue returns Max encoded for the max_value field of Parquet
+// statistics, or nil if HasMinMax is false.
+func (st *Statistics) MaxValue() []byte {
+	if !st.HasMinMax() {
+		return nil
+	}
+	return AppendPlain(nil, []float16.Float16{st.Max()})
+}
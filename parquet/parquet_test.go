@@ -0,0 +1,141 @@
+// Copyright 2019 Montgomery Edwards⁴⁴⁸ and Faye Amacker
+
+package parquet_test
+
+import (
+	"bytes"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/x448/float16"
+	"github.com/x448/float16/parquet"
+)
+
+var values = []float16.Float16{0x3c00, 0xbc00, 0x7e01, 0x8001}
+
+func TestPlain(t *testing.T) {
+	want := []byte{0xaa, 0x00, 0x3c, 0x00, 0xbc, 0x01, 0x7e, 0x01, 0x80}
+	for _, prefix := range [][]byte{{0xaa}, make([]byte, 1, 100)} {
+		prefix[0] = 0xaa
+		b := parquet.AppendPlain(prefix, values)
+		if !bytes.Equal(b, want) {
+			t.Errorf("AppendPlain(cap %d) = % x, wanted % x", cap(prefix), b, want)
+		}
+	}
+	if got, err := parquet.DecodePlain(want[1:]); err != nil || !reflect.DeepEqual(got, values) {
+		t.Errorf("DecodePlain = %v, %v, wanted %v, nil", got, err, values)
+	}
+	if got, err := parquet.DecodePlain(want); got != nil || err != parquet.ErrLength {
+		t.Errorf("DecodePlain(odd) = %v, %v, wanted nil, %v", got, err, parquet.ErrLength)
+	}
+}
+
+// Test that appending one value at a time grows b like append does, instead
+// of reallocating on every call.
+func TestAppendPlainGrowth(t *testing.T) {
+	allocs := testing.AllocsPerRun(10, func() {
+		var b []byte
+		for i := 0; i < 1000; i++ {
+			b = parquet.AppendPlain(b, values[:1])
+		}
+	})
+	if allocs > 30 {
+		t.Errorf("1000 AppendPlain calls allocated %v times, wanted at most 30", allocs)
+	}
+}
+
+func TestByteStreamSplit(t *testing.T) {
+	want := []byte{0xaa, 0x00, 0x00, 0x01, 0x01, 0x3c, 0xbc, 0x7e, 0x80}
+	for _, prefix := range [][]byte{{0xaa}, make([]byte, 1, 100)} {
+		prefix[0] = 0xaa
+		b := parquet.AppendByteStreamSplit(prefix, values)
+		if !bytes.Equal(b, want) {
+			t.Errorf("AppendByteStreamSplit(cap %d) = % x, wanted % x", cap(prefix), b, want)
+		}
+	}
+	if got, err := parquet.DecodeByteStreamSplit(want[1:]); err != nil || !reflect.DeepEqual(got, values) {
+		t.Errorf("DecodeByteStreamSplit = %v, %v, wanted %v, nil", got, err, values)
+	}
+	if got, err := parquet.DecodeByteStreamSplit(want); got != nil || err != parquet.ErrLength {
+		t.Errorf("DecodeByteStreamSplit(odd) = %v, %v, wanted nil, %v", got, err, parquet.ErrLength)
+	}
+}
+
+func TestStatistics(t *testing.T) {
+	nan, negZero := float16.NaN(), float16.Frombits(0x8000)
+	for _, tc := range []struct {
+		name     string
+		values   []float16.Float16
+		min, max float16.Float16
+	}{
+		{"ordered by value, not bits", []float16.Float16{0x3c00, 0xc000, 0x0001, 0xbc00}, 0xc000, 0x3c00},
+		{"NaNs excluded", []float16.Float16{nan, 0x4000, 0xfe00, 0x3c00, 0x7c01}, 0x3c00, 0x4000},
+		{"infinities", []float16.Float16{0x7c00, 0xfc00}, 0xfc00, 0x7c00},
+		{"+0 only", []float16.Float16{0}, negZero, 0},
+		{"-0 only", []float16.Float16{negZero}, negZero, 0},
+		{"zero max", []float16.Float16{negZero, 0xbc00}, 0xbc00, 0},
+		{"zero min", []float16.Float16{0x3c00, 0}, negZero, 0x3c00},
+		{"subnormals", []float16.Float16{0x8001, 0x0001, 0x8002}, 0x8002, 0x0001},
+	} {
+		var st parquet.Statistics
+		st.Update(tc.values)
+		if !st.HasMinMax() || st.Min() != tc.min || st.Max() != tc.max {
+			t.Errorf("%s: min, max = 0x%04x, 0x%04x, wanted 0x%04x, 0x%04x", tc.name, st.Min().Bits(), st.Max().Bits(), tc.min.Bits(), tc.max.Bits())
+		}
+		minValue := []byte{byte(tc.min), byte(tc.min >> 8)}
+		maxValue := []byte{byte(tc.max), byte(tc.max >> 8)}
+		if !bytes.Equal(st.MinValue(), minValue) || !bytes.Equal(st.MaxValue(), maxValue) {
+			t.Errorf("%s: MinValue, MaxValue = % x, % x, wanted % x, % x", tc.name, st.MinValue(), st.MaxValue(), minValue, maxValue)
+		}
+	}
+}
+
+func TestStatisticsCounts(t *testing.T) {
+	var st parquet.Statistics
+	if st.HasMinMax() || !st.Min().IsNaN() || !st.Max().IsNaN() || st.MinValue() != nil || st.MaxValue() != nil {
+		t.Errorf("zero Statistics has min or max")
+	}
+
+	// Only NaNs and nulls have no min or max.
+	st.Update([]float16.Float16{0x7e00, 0xfc01})
+	st.UpdateNulls(3)
+	st.UpdateNulls(2)
+	if st.HasMinMax() || st.MinValue() != nil || st.NaNCount() != 2 || st.NullCount() != 5 {
+		t.Errorf("HasMinMax, NaNCount, NullCount = %t, %d, %d, wanted false, 2, 5", st.HasMinMax(), st.NaNCount(), st.NullCount())
+	}
+
+	// Every Float16 value.
+	all := make([]float16.Float16, 1<<16)
+	for i := range all {
+		all[i] = float16.Frombits(uint16(i))
+	}
+	st = parquet.Statistics{}
+	st.Update(all)
+	if st.Min() != 0xfc00 || st.Max() != 0x7c00 || st.NaNCount() != 2046 || st.NullCount() != 0 {
+		t.Errorf("all values: min, max, NaNCount = 0x%04x, 0x%04x, %d, wanted 0xfc00, 0x7c00, 2046", st.Min().Bits(), st.Max().Bits(), st.NaNCount())
+	}
+}
+
+func TestStatisticsMerge(t *testing.T) {
+	rng := rand.New(rand.NewSource(46))
+	for i := 0; i < 100; i++ {
+		s := make([]float16.Float16, rng.Intn(20))
+		for j := range s {
+			s[j] = float16.Frombits(uint16(rng.Intn(1 << 16)))
+		}
+		split := rng.Intn(len(s) + 1)
+
+		var whole, a, b parquet.Statistics
+		whole.Update(s)
+		whole.UpdateNulls(3)
+		a.Update(s[:split])
+		a.UpdateNulls(1)
+		b.Update(s[split:])
+		b.UpdateNulls(2)
+		a.Merge(&b)
+		if a != whole {
+			t.Fatalf("Merge of %v split at %d = %+v, wanted %+v", s, split, a, whole)
+		}
+	}
+}
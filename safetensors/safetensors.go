@@ -0,0 +1,282 @@
+// Copyright 2019 Montgomery Edwards⁴⁴⁸ and Faye Amacker
+
+// Package safetensors reads and writes safetensors files of Float16 tensors.
+//
+// A safetensors file is an 8-byte little-endian header length, a JSON header
+// describing each tensor's dtype, shape and byte range, and the little-endian
+// tensor data.  See https://github.com/huggingface/safetensors.
+package safetensors
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"sort"
+
+	"github.com/x448/float16"
+)
+
+var (
+	// ErrHeader indicates a file whose header is invalid or doesn't match its data.
+	ErrHeader = errors.New("float16/safetensors: invalid header")
+
+	// ErrNotFound indicates a tensor name that isn't in the file.
+	ErrNotFound = errors.New("float16/safetensors: tensor not found")
+
+	// ErrDType indicates a tensor whose dtype can't be returned as Float16.
+	ErrDType = errors.New("float16/safetensors: unsupported dtype")
+
+	// ErrTensor indicates a tensor passed to Write with an invalid name or a
+	// shape that doesn't match its data.
+	ErrTensor = errors.New("float16/safetensors: invalid tensor")
+)
+
+// maxHeaderLen limits the JSON header to 100 MB like the reference implementation.
+const maxHeaderLen = 100 << 20
+
+// metadataKey is the header key for the string-to-string metadata.
+const metadataKey = "__metadata__"
+
+// dtypeSizes holds the size in bytes of each dtype in the specification.
+var dtypeSizes = map[string]int{
+	"BOOL": 1, "U8": 1, "I8": 1, "F8_E5M2": 1, "F8_E4M3": 1,
+	"I16": 2, "U16": 2, "F16": 2, "BF16": 2,
+	"I32": 4, "U32": 4, "F32": 4,
+	"I64": 8, "U64": 8, "F64": 8,
+}
+
+// TensorInfo describes a tensor in a file.  DType may be one this package
+// doesn't know, such as a dtype newer than the package.  The size of such a
+// tensor is unknown, so its shape isn't checked against its data and reading
+// it returns ErrDType.
+type TensorInfo struct {
+	Name  string
+	DType string // such as "F16", "BF16" or "F32"
+	Shape []int
+
+	data  []byte
+	known bool // whether DType is in dtypeSizes
+}
+
+// header is the JSON header entry of a tensor.
+type header struct {
+	DType       string   `json:"dtype"`
+	Shape       []int    `json:"shape"`
+	DataOffsets [2]int64 `json:"data_offsets"`
+}
+
+// A File is a parsed safetensors file.
+type File struct {
+	// Metadata holds the file's optional string-to-string metadata.
+	Metadata map[string]string
+
+	tensors []TensorInfo // sorted by name
+}
+
+// Read reads a whole safetensors file from r and parses it.
+func Read(r io.Reader) (*File, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(b)
+}
+
+// Parse parses a safetensors file held in b, such as a file mapped into memory.
+// The File refers to b without copying, so b must not be changed while the
+// File is used.
+func Parse(b []byte) (*File, error) {
+	if len(b) < 8 {
+		return nil, ErrHeader
+	}
+	n := binary.LittleEndian.Uint64(b)
+	if n > maxHeaderLen || n > uint64(len(b)-8) {
+		return nil, ErrHeader
+	}
+	var entries map[string]json.RawMessage
+	if err := json.Unmarshal(b[8:8+n], &entries); err != nil {
+		return nil, ErrHeader
+	}
+	data := b[8+n:]
+
+	f := &File{}
+	type span struct{ begin, end int64 }
+	spans := make([]span, 0, len(entries))
+	for name, raw := range entries {
+		if name == metadataKey {
+			if err := json.Unmarshal(raw, &f.Metadata); err != nil {
+				return nil, ErrHeader
+			}
+			continue
+		}
+		var h header
+		if err := json.Unmarshal(raw, &h); err != nil {
+			return nil, ErrHeader
+		}
+		size, known := dtypeSizes[h.DType]
+		nbytes := int64(size)
+		for _, dim := range h.Shape {
+			if dim < 0 || dim != 0 && nbytes > math.MaxInt64/int64(dim) {
+				return nil, ErrHeader
+			}
+			nbytes *= int64(dim)
+		}
+		begin, end := h.DataOffsets[0], h.DataOffsets[1]
+		if begin < 0 || end < begin || end > int64(len(data)) || known && end-begin != nbytes {
+			return nil, ErrHeader
+		}
+		f.tensors = append(f.tensors, TensorInfo{Name: name, DType: h.DType, Shape: h.Shape, data: data[begin:end:end], known: known})
+		spans = append(spans, span{begin, end})
+	}
+
+	// The tensors must cover the data without gaps or overlaps.
+	sort.Slice(spans, func(i, j int) bool { return spans[i].begin < spans[j].begin })
+	var end int64
+	for _, s := range spans {
+		if s.begin != end {
+			return nil, ErrHeader
+		}
+		end = s.end
+	}
+	if end != int64(len(data)) {
+		return nil, ErrHeader
+	}
+
+	sort.Slice(f.tensors, func(i, j int) bool { return f.tensors[i].Name < f.tensors[j].Name })
+	return f, nil
+}
+
+// Tensors returns the file's tensors sorted by name.
+func (f *File) Tensors() []TensorInfo {
+	return f.tensors
+}
+
+// Tensor returns the named tensor and reports whether it's in the file.
+func (f *File) Tensor(name string) (TensorInfo, bool) {
+	i := sort.Search(len(f.tensors), func(i int) bool { return f.tensors[i].Name >= name })
+	if i < len(f.tensors) && f.tensors[i].Name == name {
+		return f.tensors[i], true
+	}
+	return TensorInfo{}, false
+}
+
+// Bytes returns the named tensor's little-endian data without copying.  It
+// returns ErrDType for dtypes this package doesn't know.
+func (f *File) Bytes(name string) ([]byte, error) {
+	t, ok := f.Tensor(name)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if !t.known {
+		return nil, ErrDType
+	}
+	return t.data, nil
+}
+
+// Float16s returns the values of the named F16 tensor.  Like
+// float16.BytesToFloat16s, it doesn't copy the values on little-endian hosts
+// if they're 2-byte aligned.  It returns ErrDType for other dtypes; use
+// ConvertFloat16s to convert them.
+func (f *File) Float16s(name string) ([]float16.Float16, error) {
+	t, ok := f.Tensor(name)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if t.DType != "F16" {
+		return nil, ErrDType
+	}
+	s, _ := float16.BytesToFloat16s(t.data)
+	return s, nil
+}
+
+// ConvertFloat16s returns a copy of the values of the named F16, BF16, F32 or
+// F64 tensor converted to Float16, rounding like float16.Fromfloat32 and
+// float16.Fromfloat64.  It returns ErrDType for other dtypes.
+func (f *File) ConvertFloat16s(name string) ([]float16.Float16, error) {
+	t, ok := f.Tensor(name)
+	if !ok {
+		return nil, ErrNotFound
+	}
+	b := t.data
+	switch t.DType {
+	case "F16":
+		s := make([]float16.Float16, len(b)/2)
+		float16.DecodeSlice(s, b, binary.LittleEndian)
+		return s, nil
+	case "BF16":
+		s := make([]float16.Float16, len(b)/2)
+		for i := range s {
+			s[i] = float16.Fromfloat32(math.Float32frombits(uint32(binary.LittleEndian.Uint16(b[2*i:])) << 16))
+		}
+		return s, nil
+	case "F32":
+		s := make([]float16.Float16, len(b)/4)
+		for i := range s {
+			s[i] = float16.Fromfloat32(math.Float32frombits(binary.LittleEndian.Uint32(b[4*i:])))
+		}
+		return s, nil
+	case "F64":
+		s := make([]float16.Float16, len(b)/8)
+		for i := range s {
+			s[i] = float16.Fromfloat64(math.Float64frombits(binary.LittleEndian.Uint64(b[8*i:])))
+		}
+		return s, nil
+	}
+	return nil, ErrDType
+}
+
+// A Tensor is a named F16 tensor to write.
+type Tensor struct {
+	Name  string
+	Shape []int
+	Data  []float16.Float16 // in C order, with the last index of Shape varying fastest
+}
+
+// Write writes tensors and metadata to w as a safetensors file of F16
+// tensors.  The data is written in the order of tensors.  Write returns
+// ErrTensor if a name is empty, repeated or "__metadata__", or if the product
+// of a tensor's shape isn't the length of its data.
+func Write(w io.Writer, tensors []Tensor, metadata map[string]string) error {
+	entries := make(map[string]interface{}, len(tensors)+1)
+	if len(metadata) > 0 {
+		entries[metadataKey] = metadata
+	}
+	var offset int64
+	for _, t := range tensors {
+		n := 1
+		for _, dim := range t.Shape {
+			if dim < 0 || dim != 0 && n > math.MaxInt/dim {
+				return ErrTensor
+			}
+			n *= dim
+		}
+		if _, dup := entries[t.Name]; dup || t.Name == "" || t.Name == metadataKey || n != len(t.Data) {
+			return ErrTensor
+		}
+		shape := t.Shape
+		if shape == nil {
+			shape = []int{}
+		}
+		entries[t.Name] = header{DType: "F16", Shape: shape, DataOffsets: [2]int64{offset, offset + 2*int64(n)}}
+		offset += 2 * int64(n)
+	}
+	js, _ := json.Marshal(entries) // can't fail for these types
+
+	// Pad the header with spaces so the data starts at a multiple of 8 bytes.
+	for len(js)%8 != 0 {
+		js = append(js, ' ')
+	}
+	b := make([]byte, 8, 8+len(js))
+	binary.LittleEndian.PutUint64(b, uint64(len(js)))
+	if _, err := w.Write(append(b, js...)); err != nil {
+		return err
+	}
+	for _, t := range tensors {
+		if _, err := w.Write(float16.Float16sToBytes(t.Data)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,274 @@
+// Copyright 2019 Montgomery Edwards⁴⁴⁸ and Faye Amacker
+
+package safetensors_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	"testing/iotest"
+	"unsafe"
+
+	"github.com/x448/float16"
+	"github.com/x448/float16/safetensors"
+)
+
+// file returns a safetensors file with the specified JSON header and data.
+func file(header string, data ...byte) []byte {
+	b := make([]byte, 8, 8+len(header)+len(data))
+	binary.LittleEndian.PutUint64(b, uint64(len(header)))
+	b = append(b, header...)
+	return append(b, data...)
+}
+
+func TestWrite(t *testing.T) {
+	tensors := []safetensors.Tensor{
+		{Name: "weight", Shape: []int{2, 2}, Data: []float16.Float16{0x3c00, 0x4000, 0x4200, 0x4400}},
+		{Name: "bias", Shape: []int{2}, Data: []float16.Float16{0xbc00, 0x0001}},
+		{Name: "scale", Data: []float16.Float16{0x3800}},
+		{Name: "empty", Shape: []int{0, 3}},
+	}
+	metadata := map[string]string{"format": "pt"}
+
+	var buf bytes.Buffer
+	if err := safetensors.Write(&buf, tensors, metadata); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	header := `{"__metadata__":{"format":"pt"},` +
+		`"bias":{"dtype":"F16","shape":[2],"data_offsets":[8,12]},` +
+		`"empty":{"dtype":"F16","shape":[0,3],"data_offsets":[14,14]},` +
+		`"scale":{"dtype":"F16","shape":[],"data_offsets":[12,14]},` +
+		`"weight":{"dtype":"F16","shape":[2,2],"data_offsets":[0,8]}}`
+	for len(header)%8 != 0 {
+		header += " "
+	}
+	want := file(header, 0x00, 0x3c, 0x00, 0x40, 0x00, 0x42, 0x00, 0x44, 0x00, 0xbc, 0x01, 0x00, 0x00, 0x38)
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Fatalf("Write wrote\n%q\nwanted\n%q", buf.Bytes(), want)
+	}
+
+	f, err := safetensors.Read(&buf)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if !reflect.DeepEqual(f.Metadata, metadata) {
+		t.Errorf("Metadata = %v, wanted %v", f.Metadata, metadata)
+	}
+	var names []string
+	for _, ti := range f.Tensors() {
+		names = append(names, ti.Name)
+	}
+	if want := []string{"bias", "empty", "scale", "weight"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("Tensors() names = %v, wanted %v", names, want)
+	}
+	for _, tensor := range tensors {
+		ti, ok := f.Tensor(tensor.Name)
+		if !ok || ti.DType != "F16" || len(ti.Shape) != len(tensor.Shape) {
+			t.Errorf("Tensor(%q) = %+v, %t", tensor.Name, ti, ok)
+		}
+		got, err := f.Float16s(tensor.Name)
+		if err != nil || len(got) != len(tensor.Data) {
+			t.Fatalf("Float16s(%q) = %v, %v, wanted %v", tensor.Name, got, err, tensor.Data)
+		}
+		for i := range got {
+			if got[i] != tensor.Data[i] {
+				t.Errorf("Float16s(%q)[%d] = 0x%04x, wanted 0x%04x", tensor.Name, i, got[i].Bits(), tensor.Data[i].Bits())
+			}
+		}
+	}
+}
+
+func TestFloat16sZeroCopy(t *testing.T) {
+	b := file(`{"x":{"dtype":"F16","shape":[1],"data_offsets":[0,2]}}    `, 0x00, 0x3c)
+	f, err := safetensors.Parse(b)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	s, err := f.Float16s("x")
+	if err != nil {
+		t.Fatalf("Float16s: %v", err)
+	}
+	raw, err := f.Bytes("x")
+	if err != nil || !bytes.Equal(raw, []byte{0x00, 0x3c}) {
+		t.Fatalf("Bytes = % x, %v, wanted 00 3c, nil", raw, err)
+	}
+
+	// Only aligned data on a little-endian host is shared.
+	x := uint16(1)
+	little := *(*byte)(unsafe.Pointer(&x)) == 1
+	aligned := uintptr(unsafe.Pointer(&raw[0]))%2 == 0
+	raw[1] = 0x40
+	if shared := s[0] == 0x4000; shared != (little && aligned) {
+		t.Errorf("Float16s shared=%t with little=%t aligned=%t", shared, little, aligned)
+	}
+}
+
+func TestConvertFloat16s(t *testing.T) {
+	f64s := []float64{1, -2.5, 65520, 0x1p-25, math.Inf(-1), 0x1.006p0}
+	want := []float16.Float16{0x3c00, 0xc100, 0x7c00, 0x0000, 0xfc00, 0x3c02}
+
+	var data []byte
+	for _, f := range f64s {
+		data = append(data, float16.Float16sToBytes([]float16.Float16{float16.Fromfloat64(f)})...)
+	}
+	for _, f := range f64s {
+		var b [4]byte
+		binary.LittleEndian.PutUint32(b[:], math.Float32bits(float32(f)))
+		data = append(data, b[:]...)
+	}
+	for _, f := range f64s {
+		var b [8]byte
+		binary.LittleEndian.PutUint64(b[:], math.Float64bits(f))
+		data = append(data, b[:]...)
+	}
+	// BF16 is the top half of float32.
+	bf16s := []uint16{0x3f80, 0xc020, 0x4780, 0x3300, 0xff80, 0x3f81}
+	wantBF16 := []float16.Float16{0x3c00, 0xc100, 0x7c00, 0x0000, 0xfc00, 0x3c08}
+	for _, bf := range bf16s {
+		var b [2]byte
+		binary.LittleEndian.PutUint16(b[:], bf)
+		data = append(data, b[:]...)
+	}
+	data = append(data, 1, 2, 3, 4)
+
+	f, err := safetensors.Parse(file(`{
+		"f16": {"dtype": "F16", "shape": [6], "data_offsets": [0, 12]},
+		"f32": {"dtype": "F32", "shape": [2, 3], "data_offsets": [12, 36]},
+		"f64": {"dtype": "F64", "shape": [6], "data_offsets": [36, 84]},
+		"bf16": {"dtype": "BF16", "shape": [6], "data_offsets": [84, 96]},
+		"i32": {"dtype": "I32", "shape": [1], "data_offsets": [96, 100]}
+	}`, data...))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	for name, want := range map[string][]float16.Float16{"f16": want, "f32": want, "f64": want, "bf16": wantBF16} {
+		got, err := f.ConvertFloat16s(name)
+		if err != nil {
+			t.Fatalf("ConvertFloat16s(%q): %v", name, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("ConvertFloat16s(%q) = %v, wanted %v", name, got, want)
+		}
+		if _, err := f.Float16s(name); name != "f16" && err != safetensors.ErrDType {
+			t.Errorf("Float16s(%q) = %v, wanted %v", name, err, safetensors.ErrDType)
+		}
+	}
+	if _, err := f.ConvertFloat16s("i32"); err != safetensors.ErrDType {
+		t.Errorf("ConvertFloat16s(i32) = %v, wanted %v", err, safetensors.ErrDType)
+	}
+	for _, get := range []func(string) ([]float16.Float16, error){f.Float16s, f.ConvertFloat16s} {
+		if _, err := get("missing"); err != safetensors.ErrNotFound {
+			t.Errorf("missing tensor = %v, wanted %v", err, safetensors.ErrNotFound)
+		}
+	}
+	if _, err := f.Bytes("missing"); err != safetensors.ErrNotFound {
+		t.Errorf("Bytes(missing) = %v, wanted %v", err, safetensors.ErrNotFound)
+	}
+}
+
+func TestParseUnknownDType(t *testing.T) {
+	// The size of an unknown dtype can't be checked, but its data offsets can.
+	f, err := safetensors.Parse(file(`{
+		"scale": {"dtype": "F8_E8M0", "shape": [3], "data_offsets": [0, 3]},
+		"x": {"dtype": "F16", "shape": [1], "data_offsets": [3, 5]}
+	}`, 1, 2, 3, 0x00, 0x3c))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if ti, ok := f.Tensor("scale"); !ok || ti.DType != "F8_E8M0" || !reflect.DeepEqual(ti.Shape, []int{3}) {
+		t.Errorf("Tensor(scale) = %+v, %t", ti, ok)
+	}
+	if _, err := f.Bytes("scale"); err != safetensors.ErrDType {
+		t.Errorf("Bytes(scale) = %v, wanted %v", err, safetensors.ErrDType)
+	}
+	for _, get := range []func(string) ([]float16.Float16, error){f.Float16s, f.ConvertFloat16s} {
+		if _, err := get("scale"); err != safetensors.ErrDType {
+			t.Errorf("reading scale = %v, wanted %v", err, safetensors.ErrDType)
+		}
+	}
+	if got, err := f.Float16s("x"); err != nil || len(got) != 1 || got[0] != 0x3c00 {
+		t.Errorf("Float16s(x) = %v, %v, wanted [0x3c00]", got, err)
+	}
+}
+
+func TestParseLargeShape(t *testing.T) {
+	// Sizes over 2 GiB are only limited by the data.
+	b := file(`{"x":{"dtype":"F16","shape":[65536,65536,0],"data_offsets":[0,0]}}`)
+	if _, err := safetensors.Parse(b); err != nil {
+		t.Errorf("Parse(%q) = %v, wanted nil", b, err)
+	}
+	b = file(`{"x":{"dtype":"F16","shape":[65536,65536],"data_offsets":[0,8589934592]}}`, 0, 0)
+	if _, err := safetensors.Parse(b); err != safetensors.ErrHeader {
+		t.Errorf("Parse(%q) = %v, wanted %v", b, err, safetensors.ErrHeader)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	huge := make([]byte, 8)
+	binary.LittleEndian.PutUint64(huge, 1<<40)
+	for _, b := range [][]byte{
+		nil,
+		{1, 0, 0, 0, 0, 0, 0},
+		huge,
+		file(`{"x":`),
+		file(`[]`),
+		file(`{"__metadata__":{"a":1}}`),
+		file(`{"x":[]}`),
+		file(`{"x":{"dtype":"F12","shape":[1],"data_offsets":[2,0]}}`, 0, 0),
+		file(`{"x":{"dtype":"F16","shape":[4294967296,4294967296,0],"data_offsets":[0,0]}}`),
+		file(`{"x":{"dtype":"F16","shape":[-1],"data_offsets":[0,2]}}`, 0, 0),
+		file(`{"x":{"dtype":"F16","shape":[100000,100000],"data_offsets":[0,2]}}`, 0, 0),
+		file(`{"x":{"dtype":"F16","shape":[1],"data_offsets":[0,4]}}`, 0, 0, 0, 0),
+		file(`{"x":{"dtype":"F16","shape":[1],"data_offsets":[-2,0]}}`, 0, 0),
+		file(`{"x":{"dtype":"F16","shape":[1],"data_offsets":[2,4]}}`, 0, 0),
+		file(`{"x":{"dtype":"F16","shape":[1],"data_offsets":[0,2]}}`, 0, 0, 0),
+		file(`{"x":{"dtype":"F16","shape":[1],"data_offsets":[0,2]},"y":{"dtype":"F16","shape":[1],"data_offsets":[4,6]}}`, 0, 0, 0, 0, 0, 0),
+		file(`{"x":{"dtype":"F16","shape":[2],"data_offsets":[0,4]},"y":{"dtype":"F16","shape":[1],"data_offsets":[2,4]}}`, 0, 0, 0, 0),
+	} {
+		if _, err := safetensors.Parse(b); err != safetensors.ErrHeader {
+			t.Errorf("Parse(%q) = %v, wanted %v", b, err, safetensors.ErrHeader)
+		}
+	}
+
+	errRead := errors.New("read failed")
+	if _, err := safetensors.Read(iotest.ErrReader(errRead)); err != errRead {
+		t.Errorf("Read(ErrReader) = %v, wanted %v", err, errRead)
+	}
+}
+
+// failWriter fails after n successful writes.
+type failWriter struct{ n int }
+
+func (w *failWriter) Write(b []byte) (int, error) {
+	if w.n == 0 {
+		return 0, errors.New("write failed")
+	}
+	w.n--
+	return len(b), nil
+}
+
+func TestWriteErrors(t *testing.T) {
+	one := []float16.Float16{0x3c00}
+	for _, tensors := range [][]safetensors.Tensor{
+		{{Name: "", Data: one}},
+		{{Name: "__metadata__", Data: one}},
+		{{Name: "x", Data: one}, {Name: "x", Data: one}},
+		{{Name: "x", Shape: []int{2}, Data: one}},
+		{{Name: "x", Shape: []int{-1, -1}, Data: one}},
+		{{Name: "x", Shape: []int{8, math.MaxInt/4 + 1}}}, // product wraps around to 0
+	} {
+		if err := safetensors.Write(&bytes.Buffer{}, tensors, nil); err != safetensors.ErrTensor {
+			t.Errorf("Write(%+v) = %v, wanted %v", tensors, err, safetensors.ErrTensor)
+		}
+	}
+
+	tensors := []safetensors.Tensor{{Name: "x", Data: one}}
+	for n := 0; n < 2; n++ {
+		if err := safetensors.Write(&failWriter{n}, tensors, nil); err == nil {
+			t.Errorf("Write failing after %d writes = nil, wanted error", n)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2019 Montgomery Edwards⁴⁴⁸ and Faye Amacker
+
+package float16
+
+import (
+	"math"
+)
+
+// FromFloat32s converts the values of src to Float16 and stores them in dst.
+// Like copy, it converts min(len(dst), len(src)) values and returns that number.
+// Results are identical to Fromfloat32, and FromFloat32s doesn't allocate.
+func FromFloat32s(dst []Float16, src []float32) int {
+	n := len(src)
+	if len(dst) < n {
+		n = len(dst)
+	}
+	kernel.f32sToF16s(dst[:n], src[:n])
+	return n
+}
+
+// ToFloat32s converts the values of src to float32 and stores them in dst.
+// Like copy, it converts min(len(dst), len(src)) values and returns that number.
+// Results are identical to Float32, and ToFloat32s doesn't allocate.
+func ToFloat32s(dst []float32, src []Float16) int {
+	n := len(src)
+	if len(dst) < n {
+		n = len(dst)
+	}
+	kernel.f16sToF32s(dst[:n], src[:n])
+	return n
+}
+
+// FromFloat64s converts the values of src to Float16 and stores them in dst.
+// Like copy, it converts min(len(dst), len(src)) values and returns that number.
+// Results are identical to Fromfloat64, and FromFloat64s doesn't allocate.
+func FromFloat64s(dst []Float16, src []float64) int {
+	n := len(src)
+	if len(dst) < n {
+		n = len(dst)
+	}
+	kernel.f64sToF16s(dst[:n], src[:n])
+	return n
+}
+
+// ToFloat64s converts the values of src to float64 and stores them in dst.
+// Like copy, it converts min(len(dst), len(src)) values and returns that number.
+// Results are identical to Float64, and ToFloat64s doesn't allocate.
+func ToFloat64s(dst []float64, src []Float16) int {
+	n := len(src)
+	if len(dst) < n {
+		n = len(dst)
+	}
+	kernel.f16sToF64s(dst[:n], src[:n])
+	return n
+}
+
+// batchKernel holds the functions behind the batch conversions.  Each function
+// converts src to dst, which must have the same length, with results identical
+// to the scalar conversions.
+type batchKernel struct {
+	name       string
+	f32sToF16s func(dst []Float16, src []float32)
+	f16sToF32s func(dst []float32, src []Float16)
+	f64sToF16s func(dst []Float16, src []float64)
+	f16sToF64s func(dst []float64, src []Float16)
+}
+
+// genericKernel is the pure Go batchKernel used by purego builds, on
+// architectures without assembly, and for short tails.
+var genericKernel = batchKernel{
+	name:       "generic",
+	f32sToF16s: f32sToF16sGeneric,
+	f16sToF32s: f16sToF32sGeneric,
+	f64sToF16s: f64sToF16sGeneric,
+	f16sToF64s: f16sToF64sGeneric,
+}
+
+// kernels lists the batch kernels supported by the CPU, fastest first.
+var kernels = append(archKernels(), genericKernel)
+
+// kernel is the batch kernel used by the batch conversions.
+var kernel = kernels[0]
+
+// The generic functions convert 4 values at a time with the SWAR functions
+// and convert the rest one at a time.
+
+func f32sToF16sGeneric(dst []Float16, src []float32) {
+	dst = dst[:len(src)]
+	n := len(src) &^ 3
+	for i := 0; i < n; i += 4 {
+		s, d := src[i:i+4:i+4], dst[i:i+4:i+4]
+		lo := f32x2ToF16x2(uint64(math.Float32bits(s[0])) | uint64(math.Float32bits(s[1]))<<32)
+		hi := f32x2ToF16x2(uint64(math.Float32bits(s[2])) | uint64(math.Float32bits(s[3]))<<32)
+		d[0] = Float16(lo)
+		d[1] = Float16(lo >> 32)
+		d[2] = Float16(hi)
+		d[3] = Float16(hi >> 32)
+	}
+	for i := n; i < len(src); i++ {
+		dst[i] = Float16(f32bitsToF16bits(math.Float32bits(src[i])))
+	}
+}
+
+func f16sToF32sGeneric(dst []float32, src []Float16) {
+	dst = dst[:len(src)]
+	n := len(src) &^ 3
+	for i := 0; i < n; i += 4 {
+		s, d := src[i:i+4:i+4], dst[i:i+4:i+4]
+		hi, lo := f16x4ToF32x4(uint64(s[0]) | uint64(s[1])<<16 | uint64(s[2])<<32 | uint64(s[3])<<48)
+		d[0] = math.Float32frombits(uint32(hi)<<16 | uint32(uint16(lo)))
+		d[1] = math.Float32frombits(uint32(hi>>16)<<16 | uint32(uint16(lo>>16)))
+		d[2] = math.Float32frombits(uint32(hi>>32)<<16 | uint32(uint16(lo>>32)))
+		d[3] = math.Float32frombits(uint32(hi>>48)<<16 | uint32(uint16(lo>>48)))
+	}
+	for i := n; i < len(src); i++ {
+		dst[i] = math.Float32frombits(f16bitsToF32bits(uint16(src[i])))
+	}
+}
+
+func f64sToF16sGeneric(dst []Float16, src []float64) {
+	dst = dst[:len(src)]
+	for i, f64 := range src {
+		dst[i] = Float16(f64bitsToF16bits(math.Float64bits(f64)))
+	}
+}
+
+func f16sToF64sGeneric(dst []float64, src []Float16) {
+	dst = dst[:len(src)]
+	n := len(src) &^ 3
+	for i := 0; i < n; i += 4 {
+		s, d := src[i:i+4:i+4], dst[i:i+4:i+4]
+		hi, lo := f16x4ToF32x4(uint64(s[0]) | uint64(s[1])<<16 | uint64(s[2])<<32 | uint64(s[3])<<48)
+		d[0] = float64(math.Float32frombits(uint32(hi)<<16 | uint32(uint16(lo))))
+		d[1] = float64(math.Float32frombits(uint32(hi>>16)<<16 | uint32(uint16(lo>>16))))
+		d[2] = float64(math.Float32frombits(uint32(hi>>32)<<16 | uint32(uint16(lo>>32))))
+		d[3] = float64(math.Float32frombits(uint32(hi>>48)<<16 | uint32(uint16(lo>>48))))
+	}
+	for i := n; i < len(src); i++ {
+		dst[i] = float64(math.Float32frombits(f16bitsToF32bits(uint16(src[i]))))
+	}
+}
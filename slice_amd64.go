@@ -0,0 +1,144 @@
+// Copyright 2019 Montgomery Edwards⁴⁴⁸ and Faye Amacker
+
+//go:build !purego
+
+package float16
+
+// The assembly functions convert n values, where n is a multiple of the
+// number of values converted per loop iteration (8 or 16, see each wrapper).
+// Hardware conversions use round to nearest with ties to even and quiet
+// signaling NaNs, so results are identical to the scalar conversions.
+
+//go:noescape
+func f32ToF16F16C(dst *Float16, src *float32, n int)
+
+//go:noescape
+func f16ToF32F16C(dst *float32, src *Float16, n int)
+
+//go:noescape
+func f16ToF64F16C(dst *float64, src *Float16, n int)
+
+//go:noescape
+func f32ToF16AVX512(dst *Float16, src *float32, n int)
+
+//go:noescape
+func f16ToF32AVX512(dst *float32, src *Float16, n int)
+
+//go:noescape
+func f16ToF64AVX512(dst *float64, src *Float16, n int)
+
+//go:noescape
+func f64ToF16AVX512FP16(dst *Float16, src *float64, n int)
+
+//go:noescape
+func f16ToF64AVX512FP16(dst *float64, src *Float16, n int)
+
+// archKernels returns the batch kernels supported by the CPU, fastest first.
+// AVX512F and F16C can't convert float64 to Float16 without double rounding,
+// so their kernels use the generic function for that conversion.
+func archKernels() []batchKernel {
+	var ks []batchKernel
+	if hasAVX512FP16 {
+		ks = append(ks, batchKernel{
+			name:       "avx512fp16",
+			f32sToF16s: f32sToF16sAVX512,
+			f16sToF32s: f16sToF32sAVX512,
+			f64sToF16s: f64sToF16sAVX512FP16,
+			f16sToF64s: f16sToF64sAVX512FP16,
+		})
+	}
+	if hasAVX512 {
+		ks = append(ks, batchKernel{
+			name:       "avx512",
+			f32sToF16s: f32sToF16sAVX512,
+			f16sToF32s: f16sToF32sAVX512,
+			f64sToF16s: f64sToF16sGeneric,
+			f16sToF64s: f16sToF64sAVX512,
+		})
+	}
+	if hasF16C {
+		ks = append(ks, batchKernel{
+			name:       "f16c",
+			f32sToF16s: f32sToF16sF16C,
+			f16sToF32s: f16sToF32sF16C,
+			f64sToF16s: f64sToF16sGeneric,
+			f16sToF64s: f16sToF64sF16C,
+		})
+	}
+	return ks
+}
+
+// Each wrapper converts as many values as possible in assembly and passes
+// the tail to a kernel with smaller blocks.
+
+func f32sToF16sF16C(dst []Float16, src []float32) {
+	dst = dst[:len(src)]
+	n := len(src) &^ 7
+	if n > 0 {
+		f32ToF16F16C(&dst[0], &src[0], n)
+	}
+	f32sToF16sGeneric(dst[n:], src[n:])
+}
+
+func f16sToF32sF16C(dst []float32, src []Float16) {
+	dst = dst[:len(src)]
+	n := len(src) &^ 7
+	if n > 0 {
+		f16ToF32F16C(&dst[0], &src[0], n)
+	}
+	f16sToF32sGeneric(dst[n:], src[n:])
+}
+
+func f16sToF64sF16C(dst []float64, src []Float16) {
+	dst = dst[:len(src)]
+	n := len(src) &^ 7
+	if n > 0 {
+		f16ToF64F16C(&dst[0], &src[0], n)
+	}
+	f16sToF64sGeneric(dst[n:], src[n:])
+}
+
+func f32sToF16sAVX512(dst []Float16, src []float32) {
+	dst = dst[:len(src)]
+	n := len(src) &^ 15
+	if n > 0 {
+		f32ToF16AVX512(&dst[0], &src[0], n)
+	}
+	f32sToF16sF16C(dst[n:], src[n:])
+}
+
+func f16sToF32sAVX512(dst []float32, src []Float16) {
+	dst = dst[:len(src)]
+	n := len(src) &^ 15
+	if n > 0 {
+		f16ToF32AVX512(&dst[0], &src[0], n)
+	}
+	f16sToF32sF16C(dst[n:], src[n:])
+}
+
+func f16sToF64sAVX512(dst []float64, src []Float16) {
+	dst = dst[:len(src)]
+	n := len(src) &^ 15
+	if n > 0 {
+		f16ToF64AVX512(&dst[0], &src[0], n)
+	}
+	f16sToF64sF16C(dst[n:], src[n:])
+}
+
+func f64sToF16sAVX512FP16(dst []Float16, src []float64) {
+	dst = dst[:len(src)]
+	n := len(src) &^ 7
+	if n > 0 {
+		f64ToF16AVX512FP16(&dst[0], &src[0], n)
+	}
+	f64sToF16sGeneric(dst[n:], src[n:])
+}
+
+func f16sToF64sAVX512FP16(dst []float64, src []Float16) {
+	dst = dst[:len(src)]
+	n := len(src) &^ 7
+	if n > 0 {
+		f16ToF64AVX512FP16(&dst[0], &src[0], n)
+	}
+	f16sToF64sGeneric(dst[n:], src[n:])
+}
@@ -0,0 +1,270 @@
+// Copyright 2019 Montgomery Edwards⁴⁴⁸ and Faye Amacker
+
+//go:build !purego
+
+#include "textflag.h"
+
+// The immediate operand 0 of VCVTPS2PH selects round to nearest with ties
+// to even regardless of MXCSR.
+
+// func f32ToF16F16C(dst *Float16, src *float32, n int)
+// n must be a multiple of 8.
+TEXT ·f32ToF16F16C(SB), NOSPLIT, $0-24
+	MOVQ dst+0(FP), DI
+	MOVQ src+8(FP), SI
+	MOVQ n+16(FP), CX
+	CMPQ CX, $32
+	JB   loop8
+
+loop32:
+	VMOVUPS   (SI), Y0
+	VMOVUPS   32(SI), Y1
+	VMOVUPS   64(SI), Y2
+	VMOVUPS   96(SI), Y3
+	VCVTPS2PH $0, Y0, X0
+	VCVTPS2PH $0, Y1, X1
+	VCVTPS2PH $0, Y2, X2
+	VCVTPS2PH $0, Y3, X3
+	VMOVDQU   X0, (DI)
+	VMOVDQU   X1, 16(DI)
+	VMOVDQU   X2, 32(DI)
+	VMOVDQU   X3, 48(DI)
+	ADDQ      $128, SI
+	ADDQ      $64, DI
+	SUBQ      $32, CX
+	CMPQ      CX, $32
+	JAE       loop32
+
+loop8:
+	TESTQ     CX, CX
+	JZ        done
+	VMOVUPS   (SI), Y0
+	VCVTPS2PH $0, Y0, X0
+	VMOVDQU   X0, (DI)
+	ADDQ      $32, SI
+	ADDQ      $16, DI
+	SUBQ      $8, CX
+	JMP       loop8
+
+done:
+	VZEROUPPER
+	RET
+
+// func f16ToF32F16C(dst *float32, src *Float16, n int)
+// n must be a multiple of 8.
+TEXT ·f16ToF32F16C(SB), NOSPLIT, $0-24
+	MOVQ dst+0(FP), DI
+	MOVQ src+8(FP), SI
+	MOVQ n+16(FP), CX
+	CMPQ CX, $32
+	JB   loop8
+
+loop32:
+	VCVTPH2PS (SI), Y0
+	VCVTPH2PS 16(SI), Y1
+	VCVTPH2PS 32(SI), Y2
+	VCVTPH2PS 48(SI), Y3
+	VMOVUPS   Y0, (DI)
+	VMOVUPS   Y1, 32(DI)
+	VMOVUPS   Y2, 64(DI)
+	VMOVUPS   Y3, 96(DI)
+	ADDQ      $64, SI
+	ADDQ      $128, DI
+	SUBQ      $32, CX
+	CMPQ      CX, $32
+	JAE       loop32
+
+loop8:
+	TESTQ     CX, CX
+	JZ        done
+	VCVTPH2PS (SI), Y0
+	VMOVUPS   Y0, (DI)
+	ADDQ      $16, SI
+	ADDQ      $32, DI
+	SUBQ      $8, CX
+	JMP       loop8
+
+done:
+	VZEROUPPER
+	RET
+
+// func f16ToF64F16C(dst *float64, src *Float16, n int)
+// n must be a multiple of 8.  Widening float32 to float64 is exact.
+TEXT ·f16ToF64F16C(SB), NOSPLIT, $0-24
+	MOVQ dst+0(FP), DI
+	MOVQ src+8(FP), SI
+	MOVQ n+16(FP), CX
+
+loop8:
+	TESTQ       CX, CX
+	JZ          done
+	VCVTPH2PS   (SI), Y0
+	VEXTRACTF128 $1, Y0, X1
+	VCVTPS2PD   X0, Y0
+	VCVTPS2PD   X1, Y1
+	VMOVUPD     Y0, (DI)
+	VMOVUPD     Y1, 32(DI)
+	ADDQ        $16, SI
+	ADDQ        $64, DI
+	SUBQ        $8, CX
+	JMP         loop8
+
+done:
+	VZEROUPPER
+	RET
+
+// func f32ToF16AVX512(dst *Float16, src *float32, n int)
+// n must be a multiple of 16.
+TEXT ·f32ToF16AVX512(SB), NOSPLIT, $0-24
+	MOVQ dst+0(FP), DI
+	MOVQ src+8(FP), SI
+	MOVQ n+16(FP), CX
+	CMPQ CX, $64
+	JB   loop16
+
+loop64:
+	VMOVUPS   (SI), Z0
+	VMOVUPS   64(SI), Z1
+	VMOVUPS   128(SI), Z2
+	VMOVUPS   192(SI), Z3
+	VCVTPS2PH $0, Z0, Y0
+	VCVTPS2PH $0, Z1, Y1
+	VCVTPS2PH $0, Z2, Y2
+	VCVTPS2PH $0, Z3, Y3
+	VMOVDQU   Y0, (DI)
+	VMOVDQU   Y1, 32(DI)
+	VMOVDQU   Y2, 64(DI)
+	VMOVDQU   Y3, 96(DI)
+	ADDQ      $256, SI
+	ADDQ      $128, DI
+	SUBQ      $64, CX
+	CMPQ      CX, $64
+	JAE       loop64
+
+loop16:
+	TESTQ     CX, CX
+	JZ        done
+	VMOVUPS   (SI), Z0
+	VCVTPS2PH $0, Z0, Y0
+	VMOVDQU   Y0, (DI)
+	ADDQ      $64, SI
+	ADDQ      $32, DI
+	SUBQ      $16, CX
+	JMP       loop16
+
+done:
+	VZEROUPPER
+	RET
+
+// func f16ToF32AVX512(dst *float32, src *Float16, n int)
+// n must be a multiple of 16.
+TEXT ·f16ToF32AVX512(SB), NOSPLIT, $0-24
+	MOVQ dst+0(FP), DI
+	MOVQ src+8(FP), SI
+	MOVQ n+16(FP), CX
+	CMPQ CX, $64
+	JB   loop16
+
+loop64:
+	VCVTPH2PS (SI), Z0
+	VCVTPH2PS 32(SI), Z1
+	VCVTPH2PS 64(SI), Z2
+	VCVTPH2PS 96(SI), Z3
+	VMOVUPS   Z0, (DI)
+	VMOVUPS   Z1, 64(DI)
+	VMOVUPS   Z2, 128(DI)
+	VMOVUPS   Z3, 192(DI)
+	ADDQ      $128, SI
+	ADDQ      $256, DI
+	SUBQ      $64, CX
+	CMPQ      CX, $64
+	JAE       loop64
+
+loop16:
+	TESTQ     CX, CX
+	JZ        done
+	VCVTPH2PS (SI), Z0
+	VMOVUPS   Z0, (DI)
+	ADDQ      $32, SI
+	ADDQ      $64, DI
+	SUBQ      $16, CX
+	JMP       loop16
+
+done:
+	VZEROUPPER
+	RET
+
+// func f16ToF64AVX512(dst *float64, src *Float16, n int)
+// n must be a multiple of 16.  Widening float32 to float64 is exact.
+TEXT ·f16ToF64AVX512(SB), NOSPLIT, $0-24
+	MOVQ dst+0(FP), DI
+	MOVQ src+8(FP), SI
+	MOVQ n+16(FP), CX
+
+loop16:
+	TESTQ     CX, CX
+	JZ        done
+	VCVTPH2PS (SI), Y0
+	VCVTPH2PS 16(SI), Y1
+	VCVTPS2PD Y0, Z0
+	VCVTPS2PD Y1, Z1
+	VMOVUPD   Z0, (DI)
+	VMOVUPD   Z1, 64(DI)
+	ADDQ      $32, SI
+	ADDQ      $128, DI
+	SUBQ      $16, CX
+	JMP       loop16
+
+done:
+	VZEROUPPER
+	RET
+
+// The Go assembler doesn't support AVX512-FP16, so these instructions are
+// encoded by hand:
+//
+//	VCVTPD2PH {rn-sae}, Z1, X0 = 62 f5 fd 18 5a c1
+//	VCVTPH2PD X1, Z0           = 62 f5 7c 48 5a c1
+
+// func f64ToF16AVX512FP16(dst *Float16, src *float64, n int)
+// n must be a multiple of 8.
+TEXT ·f64ToF16AVX512FP16(SB), NOSPLIT, $0-24
+	MOVQ dst+0(FP), DI
+	MOVQ src+8(FP), SI
+	MOVQ n+16(FP), CX
+
+loop8:
+	TESTQ   CX, CX
+	JZ      done
+	VMOVUPD (SI), Z1
+	BYTE    $0x62; BYTE $0xf5; BYTE $0xfd; BYTE $0x18; BYTE $0x5a; BYTE $0xc1
+	VMOVDQU X0, (DI)
+	ADDQ    $64, SI
+	ADDQ    $16, DI
+	SUBQ    $8, CX
+	JMP     loop8
+
+done:
+	VZEROUPPER
+	RET
+
+// func f16ToF64AVX512FP16(dst *float64, src *Float16, n int)
+// n must be a multiple of 8.
+TEXT ·f16ToF64AVX512FP16(SB), NOSPLIT, $0-24
+	MOVQ dst+0(FP), DI
+	MOVQ src+8(FP), SI
+	MOVQ n+16(FP), CX
+
+loop8:
+	TESTQ   CX, CX
+	JZ      done
+	VMOVDQU (SI), X1
+	BYTE    $0x62; BYTE $0xf5; BYTE $0x7c; BYTE $0x48; BYTE $0x5a; BYTE $0xc1
+	VMOVUPD Z0, (DI)
+	ADDQ    $16, SI
+	ADDQ    $64, DI
+	SUBQ    $8, CX
+	JMP     loop8
+
+done:
+	VZEROUPPER
+	RET
@@ -0,0 +1,76 @@
+// Copyright 2019 Montgomery Edwards⁴⁴⁸ and Faye Amacker
+
+//go:build !purego
+
+package float16
+
+// The assembly functions convert n values, where n is a multiple of 8.
+// They clear FPCR while converting, which selects round to nearest with ties
+// to even, IEEE half-precision (AHP=0), propagation of NaN payloads (DN=0)
+// and no flushing of subnormals (FZ=0, FZ16=0), so results are identical to
+// the scalar conversions even if other code changed FPCR.  FPCR is restored
+// before returning.
+
+//go:noescape
+func f32ToF16NEON(dst *Float16, src *float32, n int)
+
+//go:noescape
+func f16ToF32NEON(dst *float32, src *Float16, n int)
+
+//go:noescape
+func f64ToF16NEON(dst *Float16, src *float64, n int)
+
+//go:noescape
+func f16ToF64NEON(dst *float64, src *Float16, n int)
+
+// archKernels returns the batch kernels supported by the CPU, fastest first.
+// Go requires the FP and ASIMD (NEON) extensions on arm64, so the NEON kernel
+// is always supported.
+func archKernels() []batchKernel {
+	return []batchKernel{{
+		name:       "neon",
+		f32sToF16s: f32sToF16sNEON,
+		f16sToF32s: f16sToF32sNEON,
+		f64sToF16s: f64sToF16sNEON,
+		f16sToF64s: f16sToF64sNEON,
+	}}
+}
+
+// Each wrapper converts as many values as possible in assembly and passes
+// the tail to the generic function.
+
+func f32sToF16sNEON(dst []Float16, src []float32) {
+	dst = dst[:len(src)]
+	n := len(src) &^ 7
+	if n > 0 {
+		f32ToF16NEON(&dst[0], &src[0], n)
+	}
+	f32sToF16sGeneric(dst[n:], src[n:])
+}
+
+func f16sToF32sNEON(dst []float32, src []Float16) {
+	dst = dst[:len(src)]
+	n := len(src) &^ 7
+	if n > 0 {
+		f16ToF32NEON(&dst[0], &src[0], n)
+	}
+	f16sToF32sGeneric(dst[n:], src[n:])
+}
+
+func f64sToF16sNEON(dst []Float16, src []float64) {
+	dst = dst[:len(src)]
+	n := len(src) &^ 7
+	if n > 0 {
+		f64ToF16NEON(&dst[0], &src[0], n)
+	}
+	f64sToF16sGeneric(dst[n:], src[n:])
+}
+
+func f16sToF64sNEON(dst []float64, src []Float16) {
+	dst = dst[:len(src)]
+	n := len(src) &^ 7
+	if n > 0 {
+		f16ToF64NEON(&dst[0], &src[0], n)
+	}
+	f16sToF64sGeneric(dst[n:], src[n:])
+}
@@ -0,0 +1,113 @@
+// Copyright 2019 Montgomery Edwards⁴⁴⁸ and Faye Amacker
+
+//go:build !purego
+
+#include "textflag.h"
+
+// The Go assembler doesn't support the vector FCVTN, FCVTL and FCVTXN
+// instructions, so they're encoded by hand:
+//
+//	FCVTN   Vd.4H, Vn.4S = 0x0e216800 | n<<5 | d
+//	FCVTN2  Vd.8H, Vn.4S = 0x4e216800 | n<<5 | d
+//	FCVTL   Vd.4S, Vn.4H = 0x0e217800 | n<<5 | d
+//	FCVTL2  Vd.4S, Vn.8H = 0x4e217800 | n<<5 | d
+//	FCVTL   Vd.2D, Vn.2S = 0x0e617800 | n<<5 | d
+//	FCVTL2  Vd.2D, Vn.4S = 0x4e617800 | n<<5 | d
+//	FCVTXN  Vd.2S, Vn.2D = 0x2e616800 | n<<5 | d
+//	FCVTXN2 Vd.4S, Vn.2D = 0x6e616800 | n<<5 | d
+//
+// Converting float64 to float32 with round to odd (FCVTXN) and then to
+// Float16 with round to nearest (FCVTN) doesn't double round, because
+// float32 has more than 2 bits of precision beyond Float16.
+
+// func f32ToF16NEON(dst *Float16, src *float32, n int)
+TEXT ·f32ToF16NEON(SB), NOSPLIT, $0-24
+	MOVD dst+0(FP), R0
+	MOVD src+8(FP), R1
+	MOVD n+16(FP), R2
+	MRS  FPCR, R3
+	MSR  ZR, FPCR
+
+loop:
+	CBZ    R2, done
+	VLD1.P 32(R1), [V0.S4, V1.S4]
+	WORD   $0x0e216802 // FCVTN  V2.4H, V0.4S
+	WORD   $0x4e216822 // FCVTN2 V2.8H, V1.4S
+	VST1.P [V2.H8], 16(R0)
+	SUB    $8, R2
+	B      loop
+
+done:
+	MSR R3, FPCR
+	RET
+
+// func f16ToF32NEON(dst *float32, src *Float16, n int)
+TEXT ·f16ToF32NEON(SB), NOSPLIT, $0-24
+	MOVD dst+0(FP), R0
+	MOVD src+8(FP), R1
+	MOVD n+16(FP), R2
+	MRS  FPCR, R3
+	MSR  ZR, FPCR
+
+loop:
+	CBZ    R2, done
+	VLD1.P 16(R1), [V0.H8]
+	WORD   $0x0e217801 // FCVTL  V1.4S, V0.4H
+	WORD   $0x4e217802 // FCVTL2 V2.4S, V0.8H
+	VST1.P [V1.S4, V2.S4], 32(R0)
+	SUB    $8, R2
+	B      loop
+
+done:
+	MSR R3, FPCR
+	RET
+
+// func f64ToF16NEON(dst *Float16, src *float64, n int)
+TEXT ·f64ToF16NEON(SB), NOSPLIT, $0-24
+	MOVD dst+0(FP), R0
+	MOVD src+8(FP), R1
+	MOVD n+16(FP), R2
+	MRS  FPCR, R3
+	MSR  ZR, FPCR
+
+loop:
+	CBZ    R2, done
+	VLD1.P 64(R1), [V0.D2, V1.D2, V2.D2, V3.D2]
+	WORD   $0x2e616804 // FCVTXN  V4.2S, V0.2D
+	WORD   $0x6e616824 // FCVTXN2 V4.4S, V1.2D
+	WORD   $0x2e616845 // FCVTXN  V5.2S, V2.2D
+	WORD   $0x6e616865 // FCVTXN2 V5.4S, V3.2D
+	WORD   $0x0e216886 // FCVTN   V6.4H, V4.4S
+	WORD   $0x4e2168a6 // FCVTN2  V6.8H, V5.4S
+	VST1.P [V6.H8], 16(R0)
+	SUB    $8, R2
+	B      loop
+
+done:
+	MSR R3, FPCR
+	RET
+
+// func f16ToF64NEON(dst *float64, src *Float16, n int)
+TEXT ·f16ToF64NEON(SB), NOSPLIT, $0-24
+	MOVD dst+0(FP), R0
+	MOVD src+8(FP), R1
+	MOVD n+16(FP), R2
+	MRS  FPCR, R3
+	MSR  ZR, FPCR
+
+loop:
+	CBZ    R2, done
+	VLD1.P 16(R1), [V0.H8]
+	WORD   $0x0e217801 // FCVTL  V1.4S, V0.4H
+	WORD   $0x4e217802 // FCVTL2 V2.4S, V0.8H
+	WORD   $0x0e617823 // FCVTL  V3.2D, V1.2S
+	WORD   $0x4e617824 // FCVTL2 V4.2D, V1.4S
+	WORD   $0x0e617845 // FCVTL  V5.2D, V2.2S
+	WORD   $0x4e617846 // FCVTL2 V6.2D, V2.4S
+	VST1.P [V3.D2, V4.D2, V5.D2, V6.D2], 64(R0)
+	SUB    $8, R2
+	B      loop
+
+done:
+	MSR R3, FPCR
+	RET
@@ -0,0 +1,10 @@
+// Copyright 2019 Montgomery Edwards⁴⁴⁸ and Faye Amacker
+
+//go:build (!amd64 && !arm64) || purego
+
+package float16
+
+// archKernels returns no kernels because assembly isn't available.
+func archKernels() []batchKernel {
+	return nil
+}
@@ -0,0 +1,287 @@
+// Copyright 2019 Montgomery Edwards⁴⁴⁸ and Faye Amacker
+
+package float16_test
+
+import (
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/hex"
+	"math"
+	"testing"
+
+	"github.com/x448/float16"
+)
+
+// wantAllFromFloat32SHA512 is the SHA-512 digest of the little-endian Float16
+// results of converting all 4294967296 float32 values in ascending bit order,
+// the same digest TestAllFromFloat32 checks.
+const wantAllFromFloat32SHA512 = "08670429a475164d6c4a080969e35231c77ef7069b430b5f38af22e013796b7818bbe8f5942a6ddf26de0e1dfc67d02243f483d85729ebc3762fc2948a5ca1f8"
+
+// wantAllToFloat32SHA512 is the SHA-512 digest of the little-endian float32
+// results of converting all 65536 Float16 values in ascending bit order, the
+// same digest TestAllToFloat32 checks.
+const wantAllToFloat32SHA512 = "1a4ccec9fd7b6e83310c6b4958a25778cd95f8d4f88b19950e4b8d6932a955f7fbd96b1c9bd9b2a79c3a9d34d653f55e671f8f86e6a5a876660cd38479001aa6"
+
+// forEachKernel runs f as a subtest with each batch kernel supported by the CPU.
+func forEachKernel(t *testing.T, f func(t *testing.T)) {
+	for _, name := range float16.KernelNames() {
+		name := name
+		t.Run(name, func(t *testing.T) {
+			defer float16.UseKernel(name)()
+			f(t)
+		})
+	}
+}
+
+func TestFromFloat32s(t *testing.T) {
+	forEachKernel(t, func(t *testing.T) {
+		src := make([]float32, len(wantF32toF16bits))
+		for i, v := range wantF32toF16bits {
+			src[i] = v.in
+		}
+		// Odd lengths and offsets exercise any tail handling in batch kernels.
+		for start := 0; start < 9; start++ {
+			for end := len(src) - 9; end <= len(src); end++ {
+				dst := make([]float16.Float16, end-start)
+				if n := float16.FromFloat32s(dst, src[start:end]); n != end-start {
+					t.Fatalf("FromFloat32s returned %d, wanted %d", n, end-start)
+				}
+				for i, f16 := range dst {
+					if want := wantF32toF16bits[start+i].out; f16.Bits() != want {
+						t.Fatalf("FromFloat32s(src[%d:%d]) in f32bits=0x%08x, wanted=0x%04x, got=0x%04x.", start, end, math.Float32bits(src[start+i]), want, f16.Bits())
+					}
+				}
+			}
+		}
+	})
+}
+
+// Test FromFloat32s with all possible 4294967296 float32 input values.
+func TestAllFromFloat32s(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping TestAllFromFloat32s in short mode.")
+	}
+
+	forEachKernel(t, func(t *testing.T) {
+		const batchSize = 1 << 16
+		src := make([]float32, batchSize)
+		dst := make([]float16.Float16, batchSize)
+		buf := make([]byte, 2*batchSize)
+		h := sha512.New()
+
+		for i := uint64(0); i < 1<<32; i += batchSize {
+			for j := range src {
+				src[j] = math.Float32frombits(uint32(i) + uint32(j))
+			}
+			float16.FromFloat32s(dst, src)
+			float16.EncodeSlice(buf, dst, binary.LittleEndian)
+			_, _ = h.Write(buf)
+		}
+
+		if got := hex.EncodeToString(h.Sum(nil)); got != wantAllFromFloat32SHA512 {
+			t.Errorf("FromFloat32s: gotSHA512hex = %s", got)
+		}
+	})
+}
+
+// Test FromFloat32s near every rounding boundary and with NaN payloads, in
+// every position within the groups of values converted together.
+func TestFromFloat32sBoundaries(t *testing.T) {
+	var src []float32
+	for i := 0; i < 0x7c00; i++ {
+		lo := float16.Frombits(uint16(i)).Float32()
+		hi := float16.Frombits(uint16(i + 1)).Float32()
+		if i == 0x7bff {
+			hi = 65536 // next power of 2 after the largest finite value
+		}
+		mid := lo + (hi-lo)/2
+		for _, f32 := range []float32{mid, math.Nextafter32(mid, 0), math.Nextafter32(mid, hi), math.Nextafter32(lo, 0)} {
+			src = append(src, f32, -f32)
+		}
+	}
+	for _, u32 := range []uint32{0x00000001, 0x007fffff, 0x33000000, 0x33000001, 0x7f800001, 0x7fbfffff, 0x7fc00000, 0x7fffe000, 0x7fffffff} {
+		src = append(src, math.Float32frombits(u32), math.Float32frombits(u32|0x80000000))
+	}
+
+	forEachKernel(t, func(t *testing.T) {
+		for start := 0; start < 4; start++ {
+			dst := make([]float16.Float16, len(src)-start)
+			float16.FromFloat32s(dst, src[start:])
+			for i, f16 := range dst {
+				if want := float16.Fromfloat32(src[start+i]); f16 != want {
+					t.Fatalf("FromFloat32s in f32bits=0x%08x, wanted=0x%04x, got=0x%04x.", math.Float32bits(src[start+i]), want.Bits(), f16.Bits())
+				}
+			}
+		}
+	})
+}
+
+func TestToFloat32s(t *testing.T) {
+	forEachKernel(t, func(t *testing.T) {
+		src := make([]float16.Float16, 0x10000)
+		for i := range src {
+			src[i] = float16.Frombits(uint16(i))
+		}
+		dst := make([]float32, len(src))
+		if n := float16.ToFloat32s(dst, src); n != len(src) {
+			t.Fatalf("ToFloat32s returned %d, wanted %d", n, len(src))
+		}
+
+		buf := make([]byte, 4*len(dst))
+		for i, f32 := range dst {
+			if math.Float32bits(f32) != math.Float32bits(src[i].Float32()) {
+				t.Fatalf("ToFloat32s in f16bits=0x%04x, wanted=0x%08x, got=0x%08x.", i, math.Float32bits(src[i].Float32()), math.Float32bits(f32))
+			}
+			binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(f32))
+		}
+		digest := sha512.Sum512(buf)
+		if got := hex.EncodeToString(digest[:]); got != wantAllToFloat32SHA512 {
+			t.Errorf("ToFloat32s: gotSHA512hex = %s", got)
+		}
+
+		// Odd lengths and offsets exercise any tail handling in batch kernels.
+		for start := 0; start < 9; start++ {
+			for end := 0x7c00 - 9; end <= 0x7c00; end++ {
+				dst := make([]float32, end-start+1)
+				dst[len(dst)-1] = 42
+				float16.ToFloat32s(dst, src[start:end])
+				for i, f32 := range dst[:end-start] {
+					if f32 != src[start+i].Float32() {
+						t.Fatalf("ToFloat32s(src[%d:%d]) in f16bits=0x%04x, got=%v.", start, end, start+i, f32)
+					}
+				}
+				if dst[len(dst)-1] != 42 {
+					t.Fatalf("ToFloat32s(src[%d:%d]) wrote past the converted values", start, end)
+				}
+			}
+		}
+	})
+}
+
+func TestFromFloat64s(t *testing.T) {
+	forEachKernel(t, func(t *testing.T) {
+		src := []float64{0, math.Copysign(0, -1), 1, 1.00048828125, 1.000488281250001, 0x1p-25, 0x1.0000000000001p-25, 65519.99, 65520, math.Inf(-1), math.NaN(), math.Pi}
+		dst := make([]float16.Float16, len(src))
+		if n := float16.FromFloat64s(dst, src); n != len(src) {
+			t.Fatalf("FromFloat64s returned %d, wanted %d", n, len(src))
+		}
+		for i, f16 := range dst {
+			if want := float16.Fromfloat64(src[i]); f16 != want {
+				t.Errorf("FromFloat64s in %v, wanted=0x%04x, got=0x%04x.", src[i], want.Bits(), f16.Bits())
+			}
+		}
+
+		// Every float32 converted to float64 gives the same result as Fromfloat32.
+		src = src[:0]
+		for _, v := range wantF32toF16bits {
+			src = append(src, float64(v.in))
+		}
+		dst = make([]float16.Float16, len(src))
+		float16.FromFloat64s(dst, src)
+		for i, f16 := range dst {
+			if want := wantF32toF16bits[i].out; f16.Bits() != want {
+				t.Errorf("FromFloat64s in %v, wanted=0x%04x, got=0x%04x.", src[i], want, f16.Bits())
+			}
+		}
+	})
+}
+
+// Test FromFloat64s near every rounding boundary and with NaN payloads,
+// where hardware conversions are most likely to differ from Fromfloat64.
+func TestFromFloat64sBoundaries(t *testing.T) {
+	var src []float64
+	for i := 0; i < 0x7c00; i++ {
+		lo := float16.Frombits(uint16(i)).Float64()
+		hi := float16.Frombits(uint16(i + 1)).Float64()
+		if i == 0x7bff {
+			hi = 65536 // next power of 2 after the largest finite value
+		}
+		mid := lo + (hi-lo)/2
+		for _, f64 := range []float64{mid, math.Nextafter(mid, 0), math.Nextafter(mid, hi), math.Nextafter(lo, 0)} {
+			src = append(src, f64, -f64)
+		}
+	}
+	for _, coef := range []uint64{1, 1 << 41, 1<<42 - 1, 1 << 42, 1 << 50, 1<<51 | 1, 1<<52 - 1} {
+		src = append(src, math.Float64frombits(0x7ff0000000000000|coef), math.Float64frombits(0xfff0000000000000|coef))
+	}
+
+	forEachKernel(t, func(t *testing.T) {
+		dst := make([]float16.Float16, len(src))
+		float16.FromFloat64s(dst, src)
+		for i, f16 := range dst {
+			if want := float16.Fromfloat64(src[i]); f16 != want {
+				t.Fatalf("FromFloat64s in f64bits=0x%016x, wanted=0x%04x, got=0x%04x.", math.Float64bits(src[i]), want.Bits(), f16.Bits())
+			}
+		}
+	})
+}
+
+func TestToFloat64s(t *testing.T) {
+	forEachKernel(t, func(t *testing.T) {
+		src := make([]float16.Float16, 0x10000)
+		for i := range src {
+			src[i] = float16.Frombits(uint16(i))
+		}
+		dst := make([]float64, len(src))
+		if n := float16.ToFloat64s(dst, src); n != len(src) {
+			t.Fatalf("ToFloat64s returned %d, wanted %d", n, len(src))
+		}
+		for i, f64 := range dst {
+			if math.Float64bits(f64) != math.Float64bits(src[i].Float64()) || math.Float64bits(f64) != math.Float64bits(float64(src[i].Float32())) {
+				t.Fatalf("ToFloat64s in f16bits=0x%04x, got=0x%016x.", i, math.Float64bits(f64))
+			}
+		}
+	})
+}
+
+func TestSliceLengths(t *testing.T) {
+	forEachKernel(t, func(t *testing.T) {
+		f16s := []float16.Float16{0x3c00, 0x4000, 0x4200}
+		f32s := []float32{1, 2, 3}
+		f64s := []float64{1, 2, 3}
+
+		testCases := []struct {
+			name string
+			got  int
+			want int
+		}{
+			{"FromFloat32s short dst", float16.FromFloat32s(make([]float16.Float16, 2), f32s), 2},
+			{"FromFloat32s short src", float16.FromFloat32s(make([]float16.Float16, 4), f32s), 3},
+			{"FromFloat32s nil dst", float16.FromFloat32s(nil, f32s), 0},
+			{"ToFloat32s short dst", float16.ToFloat32s(make([]float32, 1), f16s), 1},
+			{"ToFloat32s nil src", float16.ToFloat32s(make([]float32, 4), nil), 0},
+			{"FromFloat64s short dst", float16.FromFloat64s(make([]float16.Float16, 2), f64s), 2},
+			{"FromFloat64s short src", float16.FromFloat64s(make([]float16.Float16, 4), f64s[:1]), 1},
+			{"ToFloat64s short dst", float16.ToFloat64s(make([]float64, 2), f16s), 2},
+			{"ToFloat64s short src", float16.ToFloat64s(make([]float64, 5), f16s), 3},
+		}
+		for _, tc := range testCases {
+			if tc.got != tc.want {
+				t.Errorf("%s returned %d, wanted %d", tc.name, tc.got, tc.want)
+			}
+		}
+
+		dst := []float16.Float16{0, 0, 0x1234}
+		float16.FromFloat32s(dst[:2], f32s)
+		if dst[0] != 0x3c00 || dst[1] != 0x4000 || dst[2] != 0x1234 {
+			t.Errorf("FromFloat32s into short dst stored %v", dst)
+		}
+	})
+}
+
+func TestSliceAllocs(t *testing.T) {
+	forEachKernel(t, func(t *testing.T) {
+		f16s := make([]float16.Float16, 1024)
+		f32s := make([]float32, 1024)
+		f64s := make([]float64, 1024)
+		allocs := testing.AllocsPerRun(10, func() {
+			float16.FromFloat32s(f16s, f32s)
+			float16.ToFloat32s(f32s, f16s)
+			float16.FromFloat64s(f16s, f64s)
+			float16.ToFloat64s(f64s, f16s)
+		})
+		if allocs != 0 {
+			t.Errorf("batch conversions allocated %v times, wanted 0", allocs)
+		}
+	})
+}
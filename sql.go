@@ -0,0 +1,77 @@
+// Copyright 2019 Montgomery Edwards⁴⁴⁸ and Faye Amacker
+
+package float16
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Scan satisfies the sql.Scanner interface.  It accepts float64, float32, int64,
+// []byte and string values, and rounds them to nearest with ties to even.
+// Finite values too large for Float16 and NULL return an error, and f is left
+// unchanged on error.  Use NullFloat16 for nullable columns.
+func (f *Float16) Scan(src interface{}) error {
+	var v Float16
+	switch src := src.(type) {
+	case float64:
+		v = Fromfloat64(src)
+		if !v.IsFinite() && !math.IsInf(src, 0) && !math.IsNaN(src) {
+			return fmt.Errorf("float16: converting %v: %w", src, strconv.ErrRange)
+		}
+	case float32:
+		return f.Scan(float64(src))
+	case int64:
+		// int64 values beyond 2**53 are far outside the range of Float16,
+		// so rounding to float64 first can't change the result.
+		return f.Scan(float64(src))
+	case []byte:
+		return f.UnmarshalText(src)
+	case string:
+		return f.UnmarshalText([]byte(src))
+	case nil:
+		return fmt.Errorf("float16: converting NULL to Float16 is unsupported")
+	default:
+		return fmt.Errorf("float16: unsupported Scan, storing driver.Value type %T into type float16.Float16", src)
+	}
+	*f = v
+	return nil
+}
+
+// Value satisfies the driver.Valuer interface.  It returns f as a float64,
+// which is lossless.
+func (f Float16) Value() (driver.Value, error) {
+	return f.Float64(), nil
+}
+
+// NullFloat16 represents a Float16 that may be NULL, in the same way as
+// sql.NullFloat64.  It satisfies the sql.Scanner and driver.Valuer interfaces.
+type NullFloat16 struct {
+	Float16 Float16
+	Valid   bool // Valid is true if Float16 is not NULL
+}
+
+// Scan satisfies the sql.Scanner interface.  NULL sets Valid to false, and
+// other values are converted in the same way as Float16.Scan.
+func (n *NullFloat16) Scan(src interface{}) error {
+	if src == nil {
+		n.Float16, n.Valid = 0, false
+		return nil
+	}
+	if err := n.Float16.Scan(src); err != nil {
+		return err
+	}
+	n.Valid = true
+	return nil
+}
+
+// Value satisfies the driver.Valuer interface.  It returns nil (NULL) if
+// Valid is false, and a float64 otherwise.
+func (n NullFloat16) Value() (driver.Value, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+	return n.Float16.Value()
+}
@@ -0,0 +1,122 @@
+// Copyright 2019 Montgomery Edwards⁴⁴⁸ and Faye Amacker
+
+package float16_test
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"math"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/x448/float16"
+)
+
+var (
+	_ sql.Scanner   = (*float16.Float16)(nil)
+	_ driver.Valuer = float16.Float16(0)
+	_ sql.Scanner   = (*float16.NullFloat16)(nil)
+	_ driver.Valuer = float16.NullFloat16{}
+)
+
+func TestScan(t *testing.T) {
+	testCases := []struct {
+		in   interface{}
+		want uint16
+	}{
+		{float64(0.1), 0x2e66},
+		{float64(1.0009765625 + 0.00048828125), 0x3c02}, // halfway, ties to even
+		{float64(65519.99), 0x7bff},
+		{float64(1e-10), 0x0000},
+		{math.Inf(-1), 0xfc00},
+		{math.NaN(), 0x7e00},
+		{float32(-2.5), 0xc100},
+		{int64(2048), 0x6800},
+		{int64(2049), 0x6800}, // ties to even
+		{int64(-65504), 0xfbff},
+		{[]byte("0.1"), 0x2e66},
+		{"1.5", 0x3e00},
+		{"-Inf", 0xfc00},
+	}
+	for _, tc := range testCases {
+		var got float16.Float16
+		if err := got.Scan(tc.in); err != nil {
+			t.Errorf("Scan(%#v) returned error %v", tc.in, err)
+		}
+		if got.Bits() != tc.want {
+			t.Errorf("Scan(%#v) returned 0x%04x, wanted 0x%04x", tc.in, got.Bits(), tc.want)
+		}
+	}
+
+	for _, in := range []interface{}{float64(65520), float32(-1e10), int64(1 << 62), "1e5", []byte("70000")} {
+		got := float16.Frombits(0x1234)
+		if err := got.Scan(in); !errors.Is(err, strconv.ErrRange) {
+			t.Errorf("Scan(%#v) returned error %v, wanted ErrRange", in, err)
+		}
+		if got.Bits() != 0x1234 {
+			t.Errorf("Scan(%#v) modified value on error to 0x%04x", in, got.Bits())
+		}
+	}
+
+	for _, in := range []interface{}{nil, true, time.Time{}, "abc"} {
+		got := float16.Frombits(0x1234)
+		if err := got.Scan(in); err == nil {
+			t.Errorf("Scan(%#v) returned nil error", in)
+		}
+		if got.Bits() != 0x1234 {
+			t.Errorf("Scan(%#v) modified value on error to 0x%04x", in, got.Bits())
+		}
+	}
+}
+
+func TestValue(t *testing.T) {
+	for i := 0; i < 0x10000; i++ {
+		f16 := float16.Frombits(uint16(i))
+		v, err := f16.Value()
+		if err != nil {
+			t.Fatalf("0x%04x: Value returned error %v", i, err)
+		}
+		f64, ok := v.(float64)
+		if !ok || !driver.IsValue(v) {
+			t.Fatalf("0x%04x: Value returned %T, wanted float64", i, v)
+		}
+		if f16.IsNaN() {
+			if !math.IsNaN(f64) {
+				t.Errorf("0x%04x: Value returned %v, wanted NaN", i, f64)
+			}
+			continue
+		}
+		if f64 != float64(f16.Float32()) {
+			t.Errorf("0x%04x: Value returned %v, wanted %v", i, f64, f16.Float32())
+		}
+
+		var got float16.Float16
+		if err := got.Scan(v); err != nil || got != f16 {
+			t.Errorf("0x%04x: Scan(Value()) returned 0x%04x, %v", i, got.Bits(), err)
+		}
+	}
+}
+
+func TestNullFloat16(t *testing.T) {
+	n := float16.NullFloat16{Float16: 0x3c00, Valid: true}
+	if err := n.Scan(nil); err != nil || n.Valid || n.Float16 != 0 {
+		t.Errorf("Scan(nil) returned %v, %v", n, err)
+	}
+	if v, err := n.Value(); v != nil || err != nil {
+		t.Errorf("Value() of NULL returned %v, %v", v, err)
+	}
+
+	if err := n.Scan(float64(0.1)); err != nil || !n.Valid || n.Float16 != 0x2e66 {
+		t.Errorf("Scan(0.1) returned %v, %v", n, err)
+	}
+	if v, err := n.Value(); v != float64(n.Float16.Float32()) || err != nil {
+		t.Errorf("Value() returned %v, %v", v, err)
+	}
+
+	n = float16.NullFloat16{}
+	if err := n.Scan("abc"); err == nil || n.Valid {
+		t.Errorf("Scan(abc) returned %v, %v", n, err)
+	}
+}
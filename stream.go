@@ -0,0 +1,189 @@
+// Copyright 2019 Montgomery Edwards⁴⁴⁸ and Faye Amacker
+
+package float16
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// ErrEncoderClosed indicates a write to an Encoder after Close.
+const ErrEncoderClosed = float16Error("float16: write to closed Encoder")
+
+// streamBufferLen is the number of values an Encoder or Decoder buffers.
+const streamBufferLen = 8192
+
+// StreamStats counts the values written to an Encoder.  Values written with
+// WriteFloat32s are also counted by the Precision that PrecisionFromfloat32
+// reports for them.
+type StreamStats struct {
+	Values    int64 // all values written
+	Unknown   int64 // PrecisionUnknown: subnormals that might not round-trip
+	Inexact   int64 // PrecisionInexact: rounded
+	Underflow int64 // PrecisionUnderflow: rounded to zero
+	Overflow  int64 // PrecisionOverflow: rounded to infinity
+}
+
+// An Encoder writes Float16 values to an output stream, 2 bytes each in the
+// specified byte order.  Writes are buffered, so call Close or Flush after
+// the last write.
+type Encoder struct {
+	w     io.Writer
+	order binary.ByteOrder
+	vals  []Float16 // buffered values
+	buf   []byte    // bytes of vals
+	stats StreamStats
+	err   error // first error, returned by all later calls
+}
+
+// NewEncoder returns a new Encoder that writes to w in the specified byte order.
+func NewEncoder(w io.Writer, order binary.ByteOrder) *Encoder {
+	return &Encoder{
+		w:     w,
+		order: order,
+		vals:  make([]Float16, 0, streamBufferLen),
+		buf:   make([]byte, 2*streamBufferLen),
+	}
+}
+
+// WriteFloat32s converts the values of src to Float16 like FromFloat32s
+// and writes them.
+func (e *Encoder) WriteFloat32s(src []float32) error {
+	for len(src) > 0 && e.err == nil {
+		n := len(e.vals)
+		k := FromFloat32s(e.vals[n:cap(e.vals)], src)
+		for _, f32 := range src[:k] {
+			switch PrecisionFromfloat32(f32) {
+			case PrecisionUnknown:
+				e.stats.Unknown++
+			case PrecisionInexact:
+				e.stats.Inexact++
+			case PrecisionUnderflow:
+				e.stats.Underflow++
+			case PrecisionOverflow:
+				e.stats.Overflow++
+			}
+		}
+		e.add(n+k, k)
+		src = src[k:]
+	}
+	return e.err
+}
+
+// WriteFloat16s writes the values of src.
+func (e *Encoder) WriteFloat16s(src []Float16) error {
+	for len(src) > 0 && e.err == nil {
+		n := len(e.vals)
+		k := copy(e.vals[n:cap(e.vals)], src)
+		e.add(n+k, k)
+		src = src[k:]
+	}
+	return e.err
+}
+
+// add extends the buffered values to n after k values were stored, and flushes
+// them if the buffer is full.
+func (e *Encoder) add(n, k int) {
+	e.vals = e.vals[:n]
+	e.stats.Values += int64(k)
+	if n == cap(e.vals) {
+		e.Flush()
+	}
+}
+
+// Flush writes any buffered values to the underlying io.Writer.
+func (e *Encoder) Flush() error {
+	if e.err != nil || len(e.vals) == 0 {
+		return e.err
+	}
+	n := EncodeSlice(e.buf, e.vals, e.order)
+	e.vals = e.vals[:0]
+	_, e.err = e.w.Write(e.buf[:2*n])
+	return e.err
+}
+
+// Close flushes any buffered values.  Later writes return ErrEncoderClosed.
+// Close doesn't close the underlying io.Writer.
+func (e *Encoder) Close() error {
+	err := e.Flush()
+	if e.err == nil {
+		e.err = ErrEncoderClosed
+	}
+	return err
+}
+
+// Stats returns the counts of values written so far.
+func (e *Encoder) Stats() StreamStats {
+	return e.stats
+}
+
+// A Decoder reads Float16 values from an input stream, 2 bytes each in the
+// specified byte order.  Reads are buffered, so a Decoder may read more
+// bytes from the underlying io.Reader than it returns values for.
+type Decoder struct {
+	r          io.Reader
+	order      binary.ByteOrder
+	buf        []byte
+	start, end int       // unread bytes are buf[start:end]
+	vals       []Float16 // scratch for ReadFloat32s
+	err        error     // error from r, returned once buf is empty
+}
+
+// NewDecoder returns a new Decoder that reads from r in the specified byte order.
+func NewDecoder(r io.Reader, order binary.ByteOrder) *Decoder {
+	return &Decoder{
+		r:     r,
+		order: order,
+		buf:   make([]byte, 2*streamBufferLen),
+	}
+}
+
+// ReadFloat16s reads up to len(dst) values into dst and returns the number
+// read.  Like io.Reader, it returns 0, io.EOF at the end of the stream, and
+// may return fewer than len(dst) values before that.  A stream that ends in
+// the middle of a value returns io.ErrUnexpectedEOF.
+func (d *Decoder) ReadFloat16s(dst []Float16) (int, error) {
+	if len(dst) == 0 {
+		return 0, nil
+	}
+	if err := d.fill(); err != nil {
+		return 0, err
+	}
+	n := DecodeSlice(dst, d.buf[d.start:d.end], d.order)
+	d.start += 2 * n
+	return n, nil
+}
+
+// ReadFloat32s reads up to len(dst) values, converts them to float32 like
+// ToFloat32s, and stores them in dst.  It returns the number read and errors
+// like ReadFloat16s.
+func (d *Decoder) ReadFloat32s(dst []float32) (int, error) {
+	if d.vals == nil {
+		d.vals = make([]Float16, streamBufferLen)
+	}
+	if len(dst) > len(d.vals) {
+		dst = dst[:len(d.vals)]
+	}
+	n, err := d.ReadFloat16s(d.vals[:len(dst)])
+	ToFloat32s(dst, d.vals[:n])
+	return n, err
+}
+
+// fill reads from r until at least one whole value is buffered, and returns
+// an error if the stream ends or fails first.
+func (d *Decoder) fill() error {
+	for d.end-d.start < 2 {
+		if d.err != nil {
+			if d.err == io.EOF && d.end > d.start {
+				return io.ErrUnexpectedEOF
+			}
+			return d.err
+		}
+		d.end = copy(d.buf, d.buf[d.start:d.end])
+		d.start = 0
+		var n int
+		n, d.err = d.r.Read(d.buf[d.end:])
+		d.end += n
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+// Copyright 2019 Montgomery Edwards⁴⁴⁸ and Faye Amacker
+
+package float16_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"testing"
+	"testing/iotest"
+
+	"github.com/x448/float16"
+)
+
+// streamLen is more values than an Encoder or Decoder buffers.
+const streamLen = 20000
+
+func streamFloat32s() []float32 {
+	src := make([]float32, streamLen)
+	for i := range src {
+		src[i] = math.Float32frombits(uint32(i) * 0x9e3779b1)
+	}
+	return src
+}
+
+func TestEncoder(t *testing.T) {
+	src := streamFloat32s()
+	f16s := make([]float16.Float16, len(src))
+	float16.FromFloat32s(f16s, src)
+
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		want := make([]byte, 4*len(src))
+		float16.EncodeSlice(want, f16s, order)
+		float16.EncodeSlice(want[2*len(src):], f16s, order)
+
+		var buf bytes.Buffer
+		e := float16.NewEncoder(&buf, order)
+		if err := e.WriteFloat32s(src); err != nil {
+			t.Fatalf("WriteFloat32s: %v", err)
+		}
+		if err := e.WriteFloat16s(f16s); err != nil {
+			t.Fatalf("WriteFloat16s: %v", err)
+		}
+		if err := e.Close(); err != nil {
+			t.Fatalf("Close: %v", err)
+		}
+		if !bytes.Equal(buf.Bytes(), want) {
+			t.Errorf("%v Encoder wrote different bytes than EncodeSlice", order)
+		}
+		if got := e.Stats().Values; got != 2*streamLen {
+			t.Errorf("%v Stats().Values = %d, wanted %d", order, got, 2*streamLen)
+		}
+
+		if err := e.WriteFloat16s(f16s[:1]); err != float16.ErrEncoderClosed {
+			t.Errorf("WriteFloat16s after Close = %v, wanted %v", err, float16.ErrEncoderClosed)
+		}
+		if err := e.WriteFloat32s(nil); err != float16.ErrEncoderClosed {
+			t.Errorf("WriteFloat32s after Close = %v, wanted %v", err, float16.ErrEncoderClosed)
+		}
+	}
+}
+
+func TestEncoderStats(t *testing.T) {
+	src := []float32{
+		1, 0, float32(math.Inf(1)), // exact
+		0x1p-20,  // unknown
+		1.0001,   // inexact
+		0x1p-30,  // underflow
+		0x1p20,   // overflow
+		-0x1p-26, // underflow
+	}
+	var buf bytes.Buffer
+	e := float16.NewEncoder(&buf, binary.LittleEndian)
+	if err := e.WriteFloat32s(src); err != nil {
+		t.Fatalf("WriteFloat32s: %v", err)
+	}
+	if err := e.WriteFloat16s([]float16.Float16{0x7bff}); err != nil {
+		t.Fatalf("WriteFloat16s: %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("Encoder wrote %d bytes before Flush, wanted 0", buf.Len())
+	}
+	if err := e.Flush(); err != nil || buf.Len() != 2*(len(src)+1) {
+		t.Errorf("Flush = %v after %d bytes, wanted nil after %d", err, buf.Len(), 2*(len(src)+1))
+	}
+	want := float16.StreamStats{Values: 9, Unknown: 1, Inexact: 1, Underflow: 2, Overflow: 1}
+	if got := e.Stats(); got != want {
+		t.Errorf("Stats() = %+v, wanted %+v", got, want)
+	}
+}
+
+func TestEncoderWriteError(t *testing.T) {
+	e := float16.NewEncoder(errWriter{}, binary.LittleEndian)
+	if err := e.WriteFloat32s(make([]float32, 10)); err != nil {
+		t.Fatalf("buffered WriteFloat32s: %v", err)
+	}
+	if err := e.Close(); err == nil || err == float16.ErrEncoderClosed {
+		t.Errorf("Close = %v, wanted write error", err)
+	}
+
+	e = float16.NewEncoder(errWriter{}, binary.LittleEndian)
+	if err := e.WriteFloat16s(make([]float16.Float16, streamLen)); err == nil {
+		t.Errorf("WriteFloat16s = nil, wanted write error")
+	}
+	if err := e.Flush(); err == nil {
+		t.Errorf("Flush = nil, wanted write error")
+	}
+}
+
+func TestDecoder(t *testing.T) {
+	src := make([]float16.Float16, streamLen)
+	for i := range src {
+		src[i] = float16.Frombits(uint16(i * 3))
+	}
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		data := make([]byte, 2*len(src))
+		float16.EncodeSlice(data, src, order)
+
+		// Odd-sized reads split values across reads.
+		d := float16.NewDecoder(iotest.HalfReader(bytes.NewReader(data)), order)
+		var got []float16.Float16
+		buf := make([]float16.Float16, 777)
+		for {
+			n, err := d.ReadFloat16s(buf)
+			got = append(got, buf[:n]...)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("ReadFloat16s: %v", err)
+			}
+		}
+		if len(got) != len(src) {
+			t.Fatalf("%v ReadFloat16s read %d values, wanted %d", order, len(got), len(src))
+		}
+		for i := range src {
+			if got[i] != src[i] {
+				t.Fatalf("%v ReadFloat16s [%d] = 0x%04x, wanted 0x%04x", order, i, got[i].Bits(), src[i].Bits())
+			}
+		}
+
+		d = float16.NewDecoder(iotest.OneByteReader(bytes.NewReader(data)), order)
+		var got32 []float32
+		buf32 := make([]float32, streamLen)
+		for {
+			n, err := d.ReadFloat32s(buf32)
+			got32 = append(got32, buf32[:n]...)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("ReadFloat32s: %v", err)
+			}
+		}
+		if len(got32) != len(src) {
+			t.Fatalf("%v ReadFloat32s read %d values, wanted %d", order, len(got32), len(src))
+		}
+		for i := range src {
+			if math.Float32bits(got32[i]) != math.Float32bits(src[i].Float32()) {
+				t.Fatalf("%v ReadFloat32s [%d] = 0x%08x, wanted 0x%08x", order, i, math.Float32bits(got32[i]), math.Float32bits(src[i].Float32()))
+			}
+		}
+	}
+}
+
+func TestDecoderErrors(t *testing.T) {
+	d := float16.NewDecoder(bytes.NewReader([]byte{0x00, 0x3c, 0x00}), binary.LittleEndian)
+	if n, err := d.ReadFloat16s(nil); n != 0 || err != nil {
+		t.Errorf("ReadFloat16s(nil) = %d, %v, wanted 0, nil", n, err)
+	}
+	buf := make([]float16.Float16, 4)
+	if n, err := d.ReadFloat16s(buf); n != 1 || err != nil || buf[0] != 0x3c00 {
+		t.Errorf("ReadFloat16s = %d, %v, 0x%04x, wanted 1, nil, 0x3c00", n, err, buf[0].Bits())
+	}
+	if n, err := d.ReadFloat16s(buf); n != 0 || err != io.ErrUnexpectedEOF {
+		t.Errorf("ReadFloat16s of half a value = %d, %v, wanted 0, %v", n, err, io.ErrUnexpectedEOF)
+	}
+
+	errRead := errors.New("read failed")
+	d = float16.NewDecoder(iotest.ErrReader(errRead), binary.LittleEndian)
+	if n, err := d.ReadFloat32s(make([]float32, 4)); n != 0 || err != errRead {
+		t.Errorf("ReadFloat32s = %d, %v, wanted 0, %v", n, err, errRead)
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright 2019 Montgomery Edwards⁴⁴⁸ and Faye Amacker
+
+package float16
+
+// SWAR (SIMD within a register) conversions used by the generic batch kernel.
+// Values are packed into the lanes of a uint64 and every lane is converted
+// with the same instructions, selecting between the results for each class
+// of value with lane masks instead of per-value branches.  Each lane keeps
+// its high bit clear before additions, so carries and borrows never cross
+// lanes, and the high bit of a lane holds the result of comparisons.
+//
+// Subnormal values need a variable shift in each lane, which takes several
+// times longer than the other classes, so it's skipped when no lane needs it.
+
+const (
+	lanes16 = 0x0001000100010001 // 1 in each 16-bit lane
+	lanes32 = 0x0000000100000001 // 1 in each 32-bit lane
+	high16  = 0x8000 * lanes16   // high bit of each 16-bit lane
+	high32  = 0x80000000 * lanes32
+)
+
+// mask16 returns all ones in the 16-bit lanes of flags with the high bit set.
+func mask16(flags uint64) uint64 {
+	return (flags >> 15 & lanes16) * 0xffff
+}
+
+// mask32 returns all ones in the 32-bit lanes of flags with the high bit set.
+func mask32(flags uint64) uint64 {
+	return (flags >> 31 & lanes32) * 0xffffffff
+}
+
+// f16x4ToF32x4 converts 4 Float16 packed in the 16-bit lanes of w to float32.
+// It returns the high and low 16 bits of each float32 in the corresponding
+// lanes of hi and lo.  Results are identical to f16bitsToF32bits.
+func f16x4ToF32x4(w uint64) (hi, lo uint64) {
+	sign := w & high16
+	x := w &^ high16 // exponent and coefficient
+
+	// Normal numbers, infinity and NaN: rebias the exponent by 127-15,
+	// or by 255-31 for infinity and NaN, and quiet NaN.
+	infNaN := (x + 0x0400*lanes16) & high16 // x >= 0x7c00
+	nan := (x + 0x03ff*lanes16) & high16    // x > 0x7c00
+	hi = x>>3&(0x0fff*lanes16) + 0x3800*lanes16 + infNaN>>15*0x3800 | nan>>9
+	lo = (x & (0x0007 * lanes16)) << 13
+
+	if sub := ^(x + 0x7c00*lanes16) & high16; sub != 0 { // x < 0x0400
+		hi, lo = f16x4Subnormals(x, mask16(sub), hi, lo)
+	}
+	return hi | sign, lo
+}
+
+// f16x4Subnormals returns hi and lo with the lanes in sub replaced by the
+// conversions of the subnormal numbers and zeros in x.
+func f16x4Subnormals(x, sub, hi, lo uint64) (uint64, uint64) {
+	// Shift the coefficient left by s until bit 10 is set, in steps of
+	// 8, 4, 2 and 1, and lower the exponent by s-1.
+	m := x & (0x03ff * lanes16)
+	k := mask16(^(m + (0x8000-1<<3)*lanes16)) // m < 1<<3
+	m = m&^k | (m&k)<<8
+	s := k & (8 * lanes16)
+	k = mask16(^(m + (0x8000-1<<7)*lanes16)) // m < 1<<7
+	m = m&^k | (m&k)<<4
+	s += k & (4 * lanes16)
+	k = mask16(^(m + (0x8000-1<<9)*lanes16)) // m < 1<<9
+	m = m&^k | (m&k)<<2
+	s += k & (2 * lanes16)
+	k = mask16(^(m + (0x8000-1<<10)*lanes16)) // m < 1<<10
+	m = m&^k | (m&k)<<1
+	s += k & (1 * lanes16)
+	subHi := m>>3&(0x00ff*lanes16) + 0x3800*lanes16 - s<<7
+	subLo := (m & (0x0007 * lanes16)) << 13
+
+	nonzero := sub &^ mask16(^(x + 0x7fff*lanes16)) // x != 0
+	hi = hi&^sub | subHi&nonzero
+	lo = lo&^sub | subLo&nonzero
+	return hi, lo
+}
+
+// f32x2ToF16x2 converts 2 float32 packed in the 32-bit lanes of w to Float16,
+// rounding to nearest with ties to even.  It returns each Float16 in the low
+// 16 bits of the corresponding lane.  Results are identical to f32bitsToF16bits.
+func f32x2ToF16x2(w uint64) uint64 {
+	sign := (w & high32) >> 16
+	a := w &^ high32 // exponent and coefficient
+
+	// Normal numbers: rebias the exponent by 127-15, then round by adding
+	// 0x0fff plus the lowest kept bit before truncating.  Rounding can carry
+	// into the exponent, up to infinity.
+	n := ((a | high32) - 0x38000000*lanes32) &^ high32
+	r := (n + 0x0fff*lanes32 + n>>13&lanes32) >> 13 & (0xffff * lanes32)
+
+	// Other lanes have a-0x38800000 outside [0, 0x0f000000).
+	d := ((a | high32) - 0x38800000*lanes32) &^ high32
+	if other := ^(a + (0x80000000-0x38800000)*lanes32) | (d + (0x80000000-0x0f000000)*lanes32); other&high32 != 0 {
+		r = f32x2Others(a, r)
+	}
+	return r | sign
+}
+
+// f32x2Others returns r with the lanes of a that aren't normal Float16
+// replaced by their conversions: subnormal numbers, zeros, infinity and NaN.
+func f32x2Others(a, r uint64) uint64 {
+	// Shift the coefficient with its implicit bit right by t = 112-exp, in
+	// steps of 8, 4, 2 and 1, keeping a sticky bit for the bits shifted out,
+	// then round like normal numbers but at bit 14.
+	c := a&(0x007fffff*lanes32) | 0x00800000*lanes32
+	t := (0x0170*lanes32 - a>>23&(0x00ff*lanes32)) & (0x00ff * lanes32)
+	k := mask32(t << 28) // t&8 != 0
+	c = c&^k | (c&k)>>8&(0x00ffffff*lanes32) | (c&k&(0xff*lanes32)+0xff*lanes32)>>8&lanes32
+	k = mask32(t << 29) // t&4 != 0
+	c = c&^k | (c&k)>>4&(0x00ffffff*lanes32) | (c&k&(0x0f*lanes32)+0x0f*lanes32)>>4&lanes32
+	k = mask32(t << 30) // t&2 != 0
+	c = c&^k | (c&k)>>2&(0x00ffffff*lanes32) | (c&k&(0x03*lanes32)+0x03*lanes32)>>2&lanes32
+	k = mask32(t << 31) // t&1 != 0
+	c = c&^k | (c&k)>>1&(0x00ffffff*lanes32) | (c&k)&lanes32
+	subR := (c + 0x1fff*lanes32 + c>>14&lanes32) >> 14 & (0xffff * lanes32)
+
+	sub := mask32(^(a + (0x80000000-0x38800000)*lanes32))  // a < 0x38800000
+	tiny := mask32(^(a + (0x80000000-0x33000000)*lanes32)) // a < 0x33000000 rounds to 0
+	inf := mask32(a + (0x80000000-0x47800000)*lanes32)     // a >= 0x47800000 rounds to infinity
+	nan := mask32(a + (0x80000000-0x7f800001)*lanes32)     // a > 0x7f800000
+	r = r&^(sub|inf) | subR&(sub^tiny) | 0x7c00*lanes32&inf
+	return r | (0x0200*lanes32|a>>13&(0x03ff*lanes32))&nan
+}
@@ -0,0 +1,93 @@
+// Copyright 2019 Montgomery Edwards⁴⁴⁸ and Faye Amacker
+
+package float16
+
+import (
+	"math"
+	"sync"
+)
+
+// TableDecoder converts Float16 to float32 and float64 by looking up each
+// value in a table of all 65536 float32 results, instead of computing it like
+// Float32 and Float64 do.  Results are identical to Float32 and Float64.
+//
+// The zero value is ready to use.  The table takes 256 KiB and is shared by
+// all TableDecoders.  It's built by the first call to NewTableDecoder or the
+// first lookup with a zero TableDecoder, so programs that don't use a
+// TableDecoder don't pay for it.  Whether a lookup beats the arithmetic
+// depends on the CPU and on how much of the table stays in cache, so
+// benchmark both.
+type TableDecoder struct {
+	table *[1 << 16]uint32 // nil in the zero value
+}
+
+var (
+	f32TableOnce sync.Once
+	f32Table     *[1 << 16]uint32
+)
+
+// NewTableDecoder returns a TableDecoder, building the shared table if it
+// hasn't been built yet.  It's safe for concurrent use.
+func NewTableDecoder() *TableDecoder {
+	return &TableDecoder{table: loadF32Table()}
+}
+
+// loadF32Table returns the shared table, building it if it hasn't been built yet.
+func loadF32Table() *[1 << 16]uint32 {
+	f32TableOnce.Do(func() {
+		t := new([1 << 16]uint32)
+		for i := range t {
+			t[i] = f16bitsToF32bits(uint16(i))
+		}
+		f32Table = t
+	})
+	return f32Table
+}
+
+// lookupTable returns the table of d, which is the shared table.
+func (d *TableDecoder) lookupTable() *[1 << 16]uint32 {
+	if d.table != nil {
+		return d.table
+	}
+	return loadF32Table()
+}
+
+// Float32 returns f converted to float32, like f.Float32().
+func (d *TableDecoder) Float32(f Float16) float32 {
+	return math.Float32frombits(d.lookupTable()[f])
+}
+
+// Float64 returns f converted to float64, like f.Float64().
+func (d *TableDecoder) Float64(f Float16) float64 {
+	return float64(math.Float32frombits(d.lookupTable()[f]))
+}
+
+// ToFloat32s converts the values of src to float32 and stores them in dst.
+// Like copy, it converts min(len(dst), len(src)) values and returns that number.
+// Results are identical to ToFloat32s, and it doesn't allocate.
+func (d *TableDecoder) ToFloat32s(dst []float32, src []Float16) int {
+	n := len(src)
+	if len(dst) < n {
+		n = len(dst)
+	}
+	t, dst, src := d.lookupTable(), dst[:n], src[:n]
+	for i, f := range src {
+		dst[i] = math.Float32frombits(t[f])
+	}
+	return n
+}
+
+// ToFloat64s converts the values of src to float64 and stores them in dst.
+// Like copy, it converts min(len(dst), len(src)) values and returns that number.
+// Results are identical to ToFloat64s, and it doesn't allocate.
+func (d *TableDecoder) ToFloat64s(dst []float64, src []Float16) int {
+	n := len(src)
+	if len(dst) < n {
+		n = len(dst)
+	}
+	t, dst, src := d.lookupTable(), dst[:n], src[:n]
+	for i, f := range src {
+		dst[i] = float64(math.Float32frombits(t[f]))
+	}
+	return n
+}
@@ -0,0 +1,86 @@
+// Copyright 2019 Montgomery Edwards⁴⁴⁸ and Faye Amacker
+
+package float16_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/x448/float16"
+)
+
+func TestTableDecoder(t *testing.T) {
+	d := float16.NewTableDecoder()
+	for i := 0; i < 1<<16; i++ {
+		f16 := float16.Frombits(uint16(i))
+		if got, want := math.Float32bits(d.Float32(f16)), math.Float32bits(f16.Float32()); got != want {
+			t.Fatalf("Float32(0x%04x) = 0x%08x, wanted 0x%08x", i, got, want)
+		}
+		if got, want := math.Float64bits(d.Float64(f16)), math.Float64bits(f16.Float64()); got != want {
+			t.Fatalf("Float64(0x%04x) = 0x%016x, wanted 0x%016x", i, got, want)
+		}
+	}
+}
+
+func TestTableDecoderZero(t *testing.T) {
+	var d float16.TableDecoder
+	if got := d.Float32(float16.Frombits(0x3c00)); got != 1 {
+		t.Errorf("Float32(0x3c00) = %v, wanted 1", got)
+	}
+	if got := d.Float64(float16.Frombits(0xc000)); got != -2 {
+		t.Errorf("Float64(0xc000) = %v, wanted -2", got)
+	}
+	dst32, dst64 := make([]float32, 1), make([]float64, 1)
+	if n := d.ToFloat32s(dst32, []float16.Float16{0x3800}); n != 1 || dst32[0] != 0.5 {
+		t.Errorf("ToFloat32s = %d, %v, wanted 1, [0.5]", n, dst32)
+	}
+	if n := d.ToFloat64s(dst64, []float16.Float16{0x3800}); n != 1 || dst64[0] != 0.5 {
+		t.Errorf("ToFloat64s = %d, %v, wanted 1, [0.5]", n, dst64)
+	}
+}
+
+func TestTableDecoderSlices(t *testing.T) {
+	d := float16.NewTableDecoder()
+	src := make([]float16.Float16, 1<<16)
+	for i := range src {
+		src[i] = float16.Frombits(uint16(i))
+	}
+
+	dst32 := make([]float32, len(src)+1)
+	if n := d.ToFloat32s(dst32, src); n != len(src) {
+		t.Fatalf("ToFloat32s returned %d, wanted %d", n, len(src))
+	}
+	for i, f16 := range src {
+		if got, want := math.Float32bits(dst32[i]), math.Float32bits(f16.Float32()); got != want {
+			t.Fatalf("ToFloat32s 0x%04x = 0x%08x, wanted 0x%08x", i, got, want)
+		}
+	}
+	if n := d.ToFloat32s(dst32[:3], src); n != 3 {
+		t.Fatalf("ToFloat32s returned %d, wanted 3", n)
+	}
+
+	dst64 := make([]float64, len(src)+1)
+	if n := d.ToFloat64s(dst64, src); n != len(src) {
+		t.Fatalf("ToFloat64s returned %d, wanted %d", n, len(src))
+	}
+	for i, f16 := range src {
+		if got, want := math.Float64bits(dst64[i]), math.Float64bits(f16.Float64()); got != want {
+			t.Fatalf("ToFloat64s 0x%04x = 0x%016x, wanted 0x%016x", i, got, want)
+		}
+	}
+	if n := d.ToFloat64s(dst64[:3], src); n != 3 {
+		t.Fatalf("ToFloat64s returned %d, wanted 3", n)
+	}
+}
+
+func TestNewTableDecoderConcurrent(t *testing.T) {
+	done := make(chan *float16.TableDecoder)
+	for i := 0; i < 4; i++ {
+		go func() { done <- float16.NewTableDecoder() }()
+	}
+	for i := 0; i < 4; i++ {
+		if d := <-done; d.Float32(float16.Frombits(0x3c00)) != 1 {
+			t.Fatalf("Float32(0x3c00) = %v, wanted 1", d.Float32(float16.Frombits(0x3c00)))
+		}
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2019 Montgomery Edwards⁴⁴⁸ and Faye Amacker
+
+package float16
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+)
+
+// MarshalText satisfies the encoding.TextMarshaler interface.  It returns the
+// shortest decimal that round-trips through binary16, or "NaN", "+Inf" or
+// "-Inf".  NaN payloads are not preserved.
+func (f Float16) MarshalText() ([]byte, error) {
+	return f.AppendFormat(make([]byte, 0, 16), 'g', -1), nil
+}
+
+// UnmarshalText satisfies the encoding.TextUnmarshaler interface.  It accepts
+// everything strconv.ParseFloat accepts, including "NaN" and "Inf", and rounds
+// the exact decimal value to nearest with ties to even.  Values too large for
+// Float16 return an error wrapping strconv.ErrRange, and f is left unchanged
+// on error.
+func (f *Float16) UnmarshalText(text []byte) error {
+	v, err := parse(string(text))
+	if err != nil {
+		return err
+	}
+	*f = v
+	return nil
+}
+
+// Set satisfies the flag.Value interface, so *Float16 can be used with
+// flag.Var.  It parses s in the same way as UnmarshalText.
+func (f *Float16) Set(s string) error {
+	return f.UnmarshalText([]byte(s))
+}
+
+// parse returns the Float16 nearest to the value represented by s.
+func parse(s string) (Float16, error) {
+	f64, err := strconv.ParseFloat(s, 64)
+	if math.IsInf(f64, 0) && err == nil {
+		return Fromfloat64(f64), nil
+	}
+	if err != nil && !math.IsInf(f64, 0) {
+		return 0, err
+	}
+	if math.IsNaN(f64) {
+		return NaN(), nil
+	}
+
+	u16 := uint16(Fromfloat64(f64))
+	u16 = (u16 & 0x8000) | breakTie(s, u16&0x7fff, math.Abs(f64))
+
+	if u16&0x7fff == 0x7c00 {
+		return Float16(u16), &strconv.NumError{Func: "ParseFloat", Num: s, Err: strconv.ErrRange}
+	}
+	return Float16(u16), nil
+}
+
+// breakTie returns the correctly rounded magnitude of s, given u16, the
+// magnitude rounded from a, the float64 nearest to s.  float64 carries more
+// than twice the precision of binary16, so rounding s to float64 first only
+// matters when a is exactly halfway between two Float16 numbers and s isn't.
+func breakTie(s string, u16 uint16, a float64) uint16 {
+	v := halfMagnitude(u16)
+	if v == a || math.IsInf(a, 0) {
+		return u16
+	}
+	other := u16 + 1
+	if v > a {
+		other = u16 - 1
+	}
+	if (v+halfMagnitude(other))/2 != a {
+		return u16
+	}
+
+	lo, hi := u16, other
+	if lo > hi {
+		lo, hi = hi, lo
+	}
+	if r, ok := new(big.Rat).SetString(s); ok {
+		switch r.Abs(r).Cmp(new(big.Rat).SetFloat64(a)) {
+		case 1:
+			return hi
+		case -1:
+			return lo
+		}
+	}
+	return u16
+}
+
+// halfMagnitude returns the value of the non-negative Float16 bits u16,
+// treating infinity as 65536, the next value after the largest Float16.
+func halfMagnitude(u16 uint16) float64 {
+	if u16 == 0x7c00 {
+		return 65536
+	}
+	return Float16(u16).Float64()
+}
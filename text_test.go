@@ -0,0 +1,218 @@
+// Copyright 2019 Montgomery Edwards⁴⁴⁸ and Faye Amacker
+
+package float16_test
+
+import (
+	"encoding/xml"
+	"errors"
+	"flag"
+	"math/big"
+	"strconv"
+	"testing"
+
+	"github.com/x448/float16"
+)
+
+// Test MarshalText and UnmarshalText round-trip all 65536 values.
+func TestTextRoundTrip(t *testing.T) {
+	for i := 0; i < 0x10000; i++ {
+		f16 := float16.Frombits(uint16(i))
+		text, err := f16.MarshalText()
+		if err != nil {
+			t.Fatalf("0x%04x: MarshalText returned error %v", i, err)
+		}
+
+		var got float16.Float16
+		if err := got.UnmarshalText(text); err != nil {
+			t.Fatalf("0x%04x: UnmarshalText(%q) returned error %v", i, text, err)
+		}
+		if f16.IsNaN() {
+			if !got.IsNaN() {
+				t.Errorf("0x%04x: UnmarshalText(%q) returned 0x%04x, wanted NaN", i, text, got.Bits())
+			}
+			continue
+		}
+		if got != f16 {
+			t.Errorf("0x%04x: UnmarshalText(%q) returned 0x%04x", i, text, got.Bits())
+		}
+	}
+}
+
+// Test UnmarshalText rounds decimals at and near every halfway point correctly,
+// including those that round to the same float64 as the halfway point.
+func TestUnmarshalTextHalfway(t *testing.T) {
+	for i := uint16(0); i < 0x7c00; i++ {
+		lo := big.NewRat(1, 1).SetFloat64(float64(float16.Frombits(i).Float32()))
+		hi := big.NewRat(65536, 1)
+		if i != 0x7bff {
+			hi.SetFloat64(float64(float16.Frombits(i + 1).Float32()))
+		}
+		mid := new(big.Rat).Add(lo, hi)
+		mid.Quo(mid, big.NewRat(2, 1))
+		tiny := new(big.Rat).Quo(mid, new(big.Rat).SetFloat64(1e40))
+
+		even := i
+		if i&1 != 0 {
+			even = i + 1
+		}
+		testCases := []struct {
+			r    *big.Rat
+			want uint16
+		}{
+			{mid, even},
+			{new(big.Rat).Add(mid, tiny), i + 1},
+			{new(big.Rat).Sub(mid, tiny), i},
+		}
+		for _, tc := range testCases {
+			for _, sign := range []string{"", "-"} {
+				s := sign + tc.r.FloatString(100)
+				want := tc.want
+				if sign == "-" {
+					want |= 0x8000
+				}
+
+				var got float16.Float16
+				err := got.UnmarshalText([]byte(s))
+				if want&0x7fff == 0x7c00 {
+					if !errors.Is(err, strconv.ErrRange) {
+						t.Errorf("UnmarshalText(%q) returned error %v, wanted ErrRange", s, err)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatalf("UnmarshalText(%q) returned error %v", s, err)
+				}
+				if got.Bits() != want {
+					t.Errorf("UnmarshalText(%q) returned 0x%04x, wanted 0x%04x", s, got.Bits(), want)
+				}
+			}
+		}
+	}
+}
+
+func TestUnmarshalText(t *testing.T) {
+	testCases := []struct {
+		in   string
+		want uint16
+	}{
+		{"1", 0x3c00},
+		{"0.1", 0x2e66},
+		{"-2.5", 0xc100},
+		{"65504", 0x7bff},
+		{"65519.99", 0x7bff},
+		{"6e-08", 0x0001},
+		{"1e-10", 0x0000},
+		{"-1e-10", 0x8000},
+		{"1e-400", 0x0000},
+		{"0x1p-24", 0x0001},
+		{"0x1.002p0", 0x3c00}, // halfway, ties to even
+		{"0x1.0020000000001p0", 0x3c01},
+		{"Inf", 0x7c00},
+		{"+Inf", 0x7c00},
+		{"-Inf", 0xfc00},
+		{"infinity", 0x7c00},
+		{"NaN", 0x7e01},
+	}
+	for _, tc := range testCases {
+		var got float16.Float16
+		if err := got.UnmarshalText([]byte(tc.in)); err != nil {
+			t.Errorf("UnmarshalText(%q) returned error %v", tc.in, err)
+		}
+		if got.Bits() != tc.want {
+			t.Errorf("UnmarshalText(%q) returned 0x%04x, wanted 0x%04x", tc.in, got.Bits(), tc.want)
+		}
+	}
+
+	for _, in := range []string{"", "abc", "1.5x", "0x1.8"} {
+		got := float16.Frombits(0x3c00)
+		err := got.UnmarshalText([]byte(in))
+		if !errors.Is(err, strconv.ErrSyntax) {
+			t.Errorf("UnmarshalText(%q) returned error %v, wanted ErrSyntax", in, err)
+		}
+		if got.Bits() != 0x3c00 {
+			t.Errorf("UnmarshalText(%q) modified value on error to 0x%04x", in, got.Bits())
+		}
+	}
+
+	for _, in := range []string{"65520", "-1e10", "1e400"} {
+		got := float16.Frombits(0x3c00)
+		err := got.UnmarshalText([]byte(in))
+		if !errors.Is(err, strconv.ErrRange) {
+			t.Errorf("UnmarshalText(%q) returned error %v, wanted ErrRange", in, err)
+		}
+		if got.Bits() != 0x3c00 {
+			t.Errorf("UnmarshalText(%q) modified value on error to 0x%04x", in, got.Bits())
+		}
+	}
+}
+
+func TestMarshalText(t *testing.T) {
+	testCases := []struct {
+		in   float16.Float16
+		want string
+	}{
+		{float16.Fromfloat32(0.1), "0.1"},
+		{float16.Fromfloat32(-2.5), "-2.5"},
+		{float16.Frombits(0x7bff), "65500"},
+		{float16.Frombits(0x8000), "-0"},
+		{float16.SmallestNonzero, "6e-08"},
+		{float16.NaN(), "NaN"},
+		{float16.Inf(1), "+Inf"},
+		{float16.Inf(-1), "-Inf"},
+	}
+	for _, tc := range testCases {
+		got, err := tc.in.MarshalText()
+		if err != nil || string(got) != tc.want {
+			t.Errorf("%#v.MarshalText() returned %q, %v, wanted %q", tc.in, got, err, tc.want)
+		}
+	}
+}
+
+func TestFlagValue(t *testing.T) {
+	scale := float16.Frombits(0x3c00)
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Var(&scale, "scale", "scale factor")
+
+	if err := fs.Parse([]string{"-scale=0.1"}); err != nil {
+		t.Fatalf("Parse returned error %v", err)
+	}
+	if scale.Bits() != 0x2e66 {
+		t.Errorf("-scale=0.1 set 0x%04x, wanted 0x2e66", scale.Bits())
+	}
+	if s := fs.Lookup("scale").Value.String(); s != "0.099975586" {
+		t.Errorf("flag value String() returned %q", s)
+	}
+
+	fs.SetOutput(discard{})
+	if err := fs.Parse([]string{"-scale=big"}); err == nil {
+		t.Errorf("-scale=big returned nil error")
+	}
+}
+
+func TestXML(t *testing.T) {
+	type pixel struct {
+		Gain  float16.Float16 `xml:"gain,attr"`
+		Value float16.Float16 `xml:"value"`
+	}
+	in := pixel{Gain: float16.Fromfloat32(1.5), Value: float16.Fromfloat32(0.1)}
+
+	b, err := xml.Marshal(in)
+	if err != nil {
+		t.Fatalf("xml.Marshal returned error %v", err)
+	}
+	if want := `<pixel gain="1.5"><value>0.1</value></pixel>`; string(b) != want {
+		t.Errorf("xml.Marshal returned %s, wanted %s", b, want)
+	}
+
+	var out pixel
+	if err := xml.Unmarshal(b, &out); err != nil {
+		t.Fatalf("xml.Unmarshal returned error %v", err)
+	}
+	if out != in {
+		t.Errorf("xml.Unmarshal returned %v, wanted %v", out, in)
+	}
+}
+
+type discard struct{}
+
+func (discard) Write(p []byte) (int, error) { return len(p), nil }
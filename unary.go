@@ -0,0 +1,101 @@
+// Copyright 2019 Montgomery Edwards⁴⁴⁸ and Faye Amacker
+
+package float16
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// ErrInvalidUnaryTableLength indicates UnaryTable.UnmarshalBinary didn't receive
+// exactly 131072 bytes.
+const ErrInvalidUnaryTableLength = float16Error("float16: invalid UnaryTable length, expected 131072 bytes")
+
+// UnaryTable holds the result of a function for each of the 65536 Float16
+// values, so the function can be applied to a slice with one lookup per value.
+// A UnaryTable is 128 KiB, and its zero value maps every value to +0.
+type UnaryTable [1 << 16]Float16
+
+// NewUnaryTable returns a UnaryTable of fn, with fn(f.Float64()) rounded to
+// Float16 by Fromfloat64.  The results are correctly rounded if fn returns the
+// correctly rounded float64 result, except in the rare cases where rounding
+// twice differs from rounding once.
+func NewUnaryTable(fn func(float64) float64) *UnaryTable {
+	t := new(UnaryTable)
+	for i := range t {
+		t[i] = Fromfloat64(fn(Float16(i).Float64()))
+	}
+	return t
+}
+
+// NewUnaryTableFloat16 returns a UnaryTable of fn.
+func NewUnaryTableFloat16(fn func(Float16) Float16) *UnaryTable {
+	t := new(UnaryTable)
+	for i := range t {
+		t[i] = fn(Float16(i))
+	}
+	return t
+}
+
+// Lookup returns the result for f.
+func (t *UnaryTable) Lookup(f Float16) Float16 {
+	return t[f]
+}
+
+// Apply stores the result for each value of src in dst.  Like copy, it
+// converts min(len(dst), len(src)) values and returns that number.  dst and
+// src may be the same slice.
+func (t *UnaryTable) Apply(dst, src []Float16) int {
+	n := len(src)
+	if len(dst) < n {
+		n = len(dst)
+	}
+	dst, src = dst[:n], src[:n]
+	for i, f := range src {
+		dst[i] = t[f]
+	}
+	return n
+}
+
+// MarshalBinary satisfies the encoding.BinaryMarshaler interface.  It returns
+// the 65536 results in order, 2 bytes each in big-endian (network) byte order
+// like Float16.MarshalBinary.
+func (t *UnaryTable) MarshalBinary() ([]byte, error) {
+	b := make([]byte, 2*len(t))
+	EncodeSlice(b, t[:], binary.BigEndian)
+	return b, nil
+}
+
+// UnmarshalBinary satisfies the encoding.BinaryUnmarshaler interface.  It decodes
+// the bytes produced by MarshalBinary and returns ErrInvalidUnaryTableLength if
+// data isn't exactly 131072 bytes.
+func (t *UnaryTable) UnmarshalBinary(data []byte) error {
+	if len(data) != 2*len(t) {
+		return ErrInvalidUnaryTableLength
+	}
+	DecodeSlice(t[:], data, binary.BigEndian)
+	return nil
+}
+
+// WriteTo satisfies the io.WriterTo interface.  It writes the bytes returned
+// by MarshalBinary to w.
+func (t *UnaryTable) WriteTo(w io.Writer) (int64, error) {
+	b, _ := t.MarshalBinary()
+	n, err := w.Write(b)
+	return int64(n), err
+}
+
+// ReadFrom satisfies the io.ReaderFrom interface.  It reads the 131072 bytes
+// written by WriteTo from r, and returns io.ErrUnexpectedEOF if r ends early.
+// t is unchanged if ReadFrom returns an error.
+func (t *UnaryTable) ReadFrom(r io.Reader) (int64, error) {
+	b := make([]byte, 2*len(t))
+	n, err := io.ReadFull(r, b)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return int64(n), err
+	}
+	return int64(n), t.UnmarshalBinary(b)
+}
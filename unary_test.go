@@ -0,0 +1,127 @@
+// Copyright 2019 Montgomery Edwards⁴⁴⁸ and Faye Amacker
+
+package float16_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"testing"
+
+	"github.com/x448/float16"
+)
+
+func sigmoid(x float64) float64 { return 1 / (1 + math.Exp(-x)) }
+
+func TestNewUnaryTable(t *testing.T) {
+	tab := float16.NewUnaryTable(sigmoid)
+	for i := 0; i < 1<<16; i++ {
+		f16 := float16.Frombits(uint16(i))
+		if got, want := tab.Lookup(f16), float16.Fromfloat64(sigmoid(f16.Float64())); got != want {
+			t.Fatalf("Lookup(0x%04x) = 0x%04x, wanted 0x%04x", i, got.Bits(), want.Bits())
+		}
+	}
+	if got := tab.Lookup(float16.Fromfloat32(0)); got != float16.Fromfloat32(0.5) {
+		t.Errorf("sigmoid(0) = %v, wanted 0.5", got)
+	}
+}
+
+func TestNewUnaryTableFloat16(t *testing.T) {
+	neg := func(f float16.Float16) float16.Float16 { return f ^ 0x8000 }
+	tab := float16.NewUnaryTableFloat16(neg)
+	for i := 0; i < 1<<16; i++ {
+		f16 := float16.Frombits(uint16(i))
+		if got, want := tab.Lookup(f16), neg(f16); got != want {
+			t.Fatalf("Lookup(0x%04x) = 0x%04x, wanted 0x%04x", i, got.Bits(), want.Bits())
+		}
+	}
+}
+
+func TestUnaryTableApply(t *testing.T) {
+	tab := float16.NewUnaryTable(math.Sqrt)
+	src := []float16.Float16{0x0000, 0x3c00, 0x4400, 0x7c00, 0xbc00}
+	want := []float16.Float16{0x0000, 0x3c00, 0x4000, 0x7c00}
+
+	dst := make([]float16.Float16, len(src)+1)
+	if n := tab.Apply(dst, src); n != len(src) {
+		t.Fatalf("Apply returned %d, wanted %d", n, len(src))
+	}
+	for i := range want {
+		if dst[i] != want[i] {
+			t.Errorf("Apply sqrt(0x%04x) = 0x%04x, wanted 0x%04x", src[i].Bits(), dst[i].Bits(), want[i].Bits())
+		}
+	}
+	if !dst[4].IsNaN() {
+		t.Errorf("Apply sqrt(-1) = 0x%04x, wanted NaN", dst[4].Bits())
+	}
+
+	// Shorter dst, then in-place twice for the fourth root of 4.
+	if n := tab.Apply(dst[:2], src); n != 2 {
+		t.Fatalf("Apply returned %d, wanted 2", n)
+	}
+	tab.Apply(src, src)
+	if n := tab.Apply(src, src); n != len(src) || src[2] != 0x3da8 {
+		t.Errorf("Apply in-place returned %d, 0x%04x, wanted %d, 0x3da8", n, src[2].Bits(), len(src))
+	}
+}
+
+func TestUnaryTableMarshalBinary(t *testing.T) {
+	tab := float16.NewUnaryTable(math.Tanh)
+	b, err := tab.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary: %v", err)
+	}
+	if len(b) != 131072 {
+		t.Fatalf("MarshalBinary returned %d bytes, wanted 131072", len(b))
+	}
+	if got := float16.FromBigEndian(b[2*0x3c00:]); got != tab.Lookup(0x3c00) {
+		t.Errorf("MarshalBinary tanh(1) = 0x%04x, wanted 0x%04x", got.Bits(), tab.Lookup(0x3c00).Bits())
+	}
+
+	var got float16.UnaryTable
+	if err := got.UnmarshalBinary(b); err != nil {
+		t.Fatalf("UnmarshalBinary: %v", err)
+	}
+	if got != *tab {
+		t.Errorf("UnmarshalBinary didn't round-trip")
+	}
+	if err := got.UnmarshalBinary(b[1:]); err != float16.ErrInvalidUnaryTableLength {
+		t.Errorf("UnmarshalBinary(short) = %v, wanted %v", err, float16.ErrInvalidUnaryTableLength)
+	}
+}
+
+type errWriter struct{}
+
+func (errWriter) Write([]byte) (int, error) { return 0, errors.New("write failed") }
+
+func TestUnaryTableWriteToReadFrom(t *testing.T) {
+	tab := float16.NewUnaryTable(math.Exp)
+	var buf bytes.Buffer
+	if n, err := tab.WriteTo(&buf); n != 131072 || err != nil {
+		t.Fatalf("WriteTo = %d, %v, wanted 131072, nil", n, err)
+	}
+	data := buf.Bytes()
+
+	var got float16.UnaryTable
+	if n, err := got.ReadFrom(bytes.NewReader(data)); n != 131072 || err != nil {
+		t.Fatalf("ReadFrom = %d, %v, wanted 131072, nil", n, err)
+	}
+	if got != *tab {
+		t.Errorf("ReadFrom didn't round-trip")
+	}
+
+	for _, r := range []io.Reader{bytes.NewReader(nil), bytes.NewReader(data[:100])} {
+		var short float16.UnaryTable
+		if _, err := short.ReadFrom(r); err != io.ErrUnexpectedEOF {
+			t.Errorf("ReadFrom(short) = %v, wanted %v", err, io.ErrUnexpectedEOF)
+		}
+		if short != (float16.UnaryTable{}) {
+			t.Errorf("ReadFrom(short) changed the table")
+		}
+	}
+
+	if _, err := tab.WriteTo(errWriter{}); err == nil {
+		t.Errorf("WriteTo(errWriter) returned nil error")
+	}
+}
@@ -0,0 +1,225 @@
+// Copyright 2019 Montgomery Edwards⁴⁴⁸ and Faye Amacker
+
+package float16
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// ErrInvalidVecLength indicates UnmarshalBinary of a Vec2, Vec3 or Vec4
+// didn't receive exactly 2 bytes per component.
+const ErrInvalidVecLength = float16Error("float16: invalid vector binary length, expected 2 bytes per component")
+
+// Vec2 is a vector of 2 Float16 values.  Its memory layout matches
+// R16G16_SFLOAT vertex and texel data and half2 in shaders: 4 bytes without
+// padding, so a []Vec2 is tightly packed.
+type Vec2 [2]Float16
+
+// Vec3 is a vector of 3 Float16 values.  Its memory layout matches
+// R16G16B16_SFLOAT vertex data: 6 bytes without padding.  Shader half3 types
+// are usually padded to 8 bytes, except Metal's packed_half3.
+type Vec3 [3]Float16
+
+// Vec4 is a vector of 4 Float16 values.  Its memory layout matches
+// R16G16B16A16_SFLOAT vertex and texel data and half4 in shaders: 8 bytes
+// without padding.
+type Vec4 [4]Float16
+
+// Vec2FromFloat32s returns a converted to Float16 values like Fromfloat32.
+func Vec2FromFloat32s(a [2]float32) Vec2 {
+	var v Vec2
+	f32sToF16sGeneric(v[:], a[:])
+	return v
+}
+
+// Vec3FromFloat32s returns a converted to Float16 values like Fromfloat32.
+func Vec3FromFloat32s(a [3]float32) Vec3 {
+	var v Vec3
+	f32sToF16sGeneric(v[:], a[:])
+	return v
+}
+
+// Vec4FromFloat32s returns a converted to Float16 values like Fromfloat32.
+func Vec4FromFloat32s(a [4]float32) Vec4 {
+	var v Vec4
+	f32sToF16sGeneric(v[:], a[:])
+	return v
+}
+
+// Float32s returns the components of v converted to float32.
+func (v Vec2) Float32s() [2]float32 {
+	var a [2]float32
+	f16sToF32sGeneric(a[:], v[:])
+	return a
+}
+
+// Float32s returns the components of v converted to float32.
+func (v Vec3) Float32s() [3]float32 {
+	var a [3]float32
+	f16sToF32sGeneric(a[:], v[:])
+	return a
+}
+
+// Float32s returns the components of v converted to float32.
+func (v Vec4) Float32s() [4]float32 {
+	var a [4]float32
+	f16sToF32sGeneric(a[:], v[:])
+	return a
+}
+
+// Dot returns the dot product of v and u computed in float32, so it doesn't
+// overflow or lose precision like a sum of Float16 products would.
+func (v Vec2) Dot(u Vec2) float32 { return dot(v[:], u[:]) }
+
+// Dot returns the dot product of v and u computed in float32.
+func (v Vec3) Dot(u Vec3) float32 { return dot(v[:], u[:]) }
+
+// Dot returns the dot product of v and u computed in float32.
+func (v Vec4) Dot(u Vec4) float32 { return dot(v[:], u[:]) }
+
+// Len returns the length of v computed in float32.
+func (v Vec2) Len() float32 { return length(v[:]) }
+
+// Len returns the length of v computed in float32.
+func (v Vec3) Len() float32 { return length(v[:]) }
+
+// Len returns the length of v computed in float32.
+func (v Vec4) Len() float32 { return length(v[:]) }
+
+// Normalize returns v scaled to length 1, computed in float32 and rounded to
+// Float16.  A zero vector is returned unchanged.
+func (v Vec2) Normalize() Vec2 {
+	normalize(v[:])
+	return v
+}
+
+// Normalize returns v scaled to length 1, computed in float32 and rounded to
+// Float16.  A zero vector is returned unchanged.
+func (v Vec3) Normalize() Vec3 {
+	normalize(v[:])
+	return v
+}
+
+// Normalize returns v scaled to length 1, computed in float32 and rounded to
+// Float16.  A zero vector is returned unchanged.
+func (v Vec4) Normalize() Vec4 {
+	normalize(v[:])
+	return v
+}
+
+func dot(v, u []Float16) float32 {
+	var sum float32
+	for i := range v {
+		// The explicit conversions round the product, so it isn't fused
+		// into an FMA on arm64, ppc64 and s390x.
+		sum = float32(sum + float32(v[i].Float32()*u[i].Float32()))
+	}
+	return sum
+}
+
+func length(v []Float16) float32 {
+	return float32(math.Sqrt(float64(dot(v, v))))
+}
+
+// normalize scales v in place to length 1, unless its length is 0.
+func normalize(v []Float16) {
+	n := length(v)
+	if n == 0 {
+		return
+	}
+	for i, f := range v {
+		v[i] = Fromfloat32(f.Float32() / n)
+	}
+}
+
+// Vec2FromLittleEndian returns the Vec2 stored in b[0:4] in little-endian
+// byte order, such as in a vertex buffer.  It panics if len(b) < 4.
+func Vec2FromLittleEndian(b []byte) Vec2 {
+	var v Vec2
+	DecodeSlice(v[:], b[:4], binary.LittleEndian)
+	return v
+}
+
+// Vec3FromLittleEndian returns the Vec3 stored in b[0:6] in little-endian
+// byte order, such as in a vertex buffer.  It panics if len(b) < 6.
+func Vec3FromLittleEndian(b []byte) Vec3 {
+	var v Vec3
+	DecodeSlice(v[:], b[:6], binary.LittleEndian)
+	return v
+}
+
+// Vec4FromLittleEndian returns the Vec4 stored in b[0:8] in little-endian
+// byte order, such as in a vertex buffer.  It panics if len(b) < 8.
+func Vec4FromLittleEndian(b []byte) Vec4 {
+	var v Vec4
+	DecodeSlice(v[:], b[:8], binary.LittleEndian)
+	return v
+}
+
+// AppendLittleEndian appends the components of v in little-endian byte order
+// to b, as GPUs read R16G16_SFLOAT data, and returns the extended buffer.
+func (v Vec2) AppendLittleEndian(b []byte) []byte {
+	return appendLittleEndian(b, v[:])
+}
+
+// AppendLittleEndian appends the components of v in little-endian byte order
+// to b, as GPUs read R16G16B16_SFLOAT data, and returns the extended buffer.
+func (v Vec3) AppendLittleEndian(b []byte) []byte {
+	return appendLittleEndian(b, v[:])
+}
+
+// AppendLittleEndian appends the components of v in little-endian byte order
+// to b, as GPUs read R16G16B16A16_SFLOAT data, and returns the extended buffer.
+func (v Vec4) AppendLittleEndian(b []byte) []byte {
+	return appendLittleEndian(b, v[:])
+}
+
+func appendLittleEndian(b []byte, v []Float16) []byte {
+	for _, f := range v {
+		b = AppendLittleEndian(b, f)
+	}
+	return b
+}
+
+// MarshalBinary satisfies the encoding.BinaryMarshaler interface.  It returns
+// the components of v in order, 2 bytes each in big-endian (network) byte
+// order like Float16.MarshalBinary.
+func (v Vec2) MarshalBinary() ([]byte, error) { return marshalVec(v[:]), nil }
+
+// MarshalBinary satisfies the encoding.BinaryMarshaler interface like
+// Vec2.MarshalBinary.
+func (v Vec3) MarshalBinary() ([]byte, error) { return marshalVec(v[:]), nil }
+
+// MarshalBinary satisfies the encoding.BinaryMarshaler interface like
+// Vec2.MarshalBinary.
+func (v Vec4) MarshalBinary() ([]byte, error) { return marshalVec(v[:]), nil }
+
+// UnmarshalBinary satisfies the encoding.BinaryUnmarshaler interface.  It
+// decodes the bytes produced by MarshalBinary and returns ErrInvalidVecLength
+// if data isn't exactly 4 bytes.
+func (v *Vec2) UnmarshalBinary(data []byte) error { return unmarshalVec(v[:], data) }
+
+// UnmarshalBinary satisfies the encoding.BinaryUnmarshaler interface.  It
+// decodes the bytes produced by MarshalBinary and returns ErrInvalidVecLength
+// if data isn't exactly 6 bytes.
+func (v *Vec3) UnmarshalBinary(data []byte) error { return unmarshalVec(v[:], data) }
+
+// UnmarshalBinary satisfies the encoding.BinaryUnmarshaler interface.  It
+// decodes the bytes produced by MarshalBinary and returns ErrInvalidVecLength
+// if data isn't exactly 8 bytes.
+func (v *Vec4) UnmarshalBinary(data []byte) error { return unmarshalVec(v[:], data) }
+
+func marshalVec(v []Float16) []byte {
+	b := make([]byte, 2*len(v))
+	EncodeSlice(b, v, binary.BigEndian)
+	return b
+}
+
+func unmarshalVec(v []Float16, data []byte) error {
+	if len(data) != 2*len(v) {
+		return ErrInvalidVecLength
+	}
+	DecodeSlice(v, data, binary.BigEndian)
+	return nil
+}
@@ -0,0 +1,149 @@
+// Copyright 2019 Montgomery Edwards⁴⁴⁸ and Faye Amacker
+
+package float16_test
+
+import (
+	"bytes"
+	"encoding"
+	"testing"
+	"unsafe"
+
+	"github.com/x448/float16"
+)
+
+var (
+	_ encoding.BinaryMarshaler   = float16.Vec2{}
+	_ encoding.BinaryUnmarshaler = (*float16.Vec3)(nil)
+	_ encoding.BinaryUnmarshaler = (*float16.Vec4)(nil)
+)
+
+func TestVecLayout(t *testing.T) {
+	if s2, s3, s4 := unsafe.Sizeof(float16.Vec2{}), unsafe.Sizeof(float16.Vec3{}), unsafe.Sizeof([2]float16.Vec4{}); s2 != 4 || s3 != 6 || s4 != 16 {
+		t.Errorf("sizes = %d, %d, %d, wanted 4, 6, 16", s2, s3, s4)
+	}
+
+	// On little-endian hosts, memory is what GPUs read.
+	v := [2]float16.Vec4{{0x3c00, 0x4000, 0x4200, 0x4400}, {0xbc00, 0, 0x7c00, 0x0001}}
+	mem := (*[16]byte)(unsafe.Pointer(&v))[:]
+	want := v[1].AppendLittleEndian(v[0].AppendLittleEndian(nil))
+	if x := uint16(1); *(*byte)(unsafe.Pointer(&x)) == 1 && !bytes.Equal(mem, want) {
+		t.Errorf("memory = % x, wanted % x", mem, want)
+	}
+}
+
+func TestVecFloat32s(t *testing.T) {
+	if got := float16.Vec2FromFloat32s([2]float32{1, -2}); got != (float16.Vec2{0x3c00, 0xc000}) || got.Float32s() != [2]float32{1, -2} {
+		t.Errorf("Vec2FromFloat32s = %v", got)
+	}
+	if got := float16.Vec3FromFloat32s([3]float32{0.5, 65520, 1e-8}); got != (float16.Vec3{0x3800, 0x7c00, 0}) || got.Float32s() != [3]float32{0.5, float16.Inf(1).Float32(), 0} {
+		t.Errorf("Vec3FromFloat32s = %v", got)
+	}
+	if got := float16.Vec4FromFloat32s([4]float32{1, 2, 3, 1.0 / 3}); got != (float16.Vec4{0x3c00, 0x4000, 0x4200, 0x3555}) || got.Float32s() != [4]float32{1, 2, 3, 0.333251953125} {
+		t.Errorf("Vec4FromFloat32s = %v", got)
+	}
+}
+
+func TestVecDot(t *testing.T) {
+	// 300*300*4 = 360000 is larger than the largest Float16.
+	v4 := float16.Vec4FromFloat32s([4]float32{300, 300, 300, -300})
+	if got := v4.Dot(v4); got != 360000 {
+		t.Errorf("Vec4.Dot = %v, wanted 360000", got)
+	}
+	if got := v4.Len(); got != 600 {
+		t.Errorf("Vec4.Len = %v, wanted 600", got)
+	}
+	v3 := float16.Vec3FromFloat32s([3]float32{1, 2, 3})
+	if got := v3.Dot(float16.Vec3FromFloat32s([3]float32{4, -5, 6})); got != 12 {
+		t.Errorf("Vec3.Dot = %v, wanted 12", got)
+	}
+	if got := v3.Len(); got*got < 13.999 || got*got > 14.001 {
+		t.Errorf("Vec3.Len = %v, wanted sqrt(14)", got)
+	}
+	v2 := float16.Vec2FromFloat32s([2]float32{3, 4})
+	if got := v2.Dot(float16.Vec2FromFloat32s([2]float32{-4, 3})); got != 0 {
+		t.Errorf("Vec2.Dot = %v, wanted 0", got)
+	}
+	if got := v2.Len(); got != 5 {
+		t.Errorf("Vec2.Len = %v, wanted 5", got)
+	}
+}
+
+func TestVecNormalize(t *testing.T) {
+	if got, want := float16.Vec2FromFloat32s([2]float32{3, 4}).Normalize(), float16.Vec2FromFloat32s([2]float32{0.6, 0.8}); got != want {
+		t.Errorf("Vec2.Normalize = %v, wanted %v", got, want)
+	}
+	// Squaring 60000 overflows Float16 but not float32.
+	if got, want := float16.Vec3FromFloat32s([3]float32{0, 60000, 0}).Normalize(), (float16.Vec3{0, 0x3c00, 0}); got != want {
+		t.Errorf("Vec3.Normalize = %v, wanted %v", got, want)
+	}
+	if got, want := float16.Vec4FromFloat32s([4]float32{1, 1, 1, 1}).Normalize(), (float16.Vec4{0x3800, 0x3800, 0x3800, 0x3800}); got != want {
+		t.Errorf("Vec4.Normalize = %v, wanted %v", got, want)
+	}
+	if got := (float16.Vec4{0x8000, 0, 0, 0}).Normalize(); got != (float16.Vec4{0x8000, 0, 0, 0}) {
+		t.Errorf("Vec4.Normalize of zero = %v, wanted it unchanged", got)
+	}
+}
+
+func TestVecBinary(t *testing.T) {
+	v2, v3, v4 := float16.Vec2{0x3c00, 0x0102}, float16.Vec3{0x3c00, 0x0102, 0xc000}, float16.Vec4{0x3c00, 0x0102, 0xc000, 0x7e00}
+	for _, tc := range []struct {
+		v      interface{ MarshalBinary() ([]byte, error) }
+		u      encoding.BinaryUnmarshaler
+		big    string
+		little []byte
+	}{
+		{v2, new(float16.Vec2), "\x3c\x00\x01\x02", v2.AppendLittleEndian(nil)},
+		{v3, new(float16.Vec3), "\x3c\x00\x01\x02\xc0\x00", v3.AppendLittleEndian(nil)},
+		{v4, new(float16.Vec4), "\x3c\x00\x01\x02\xc0\x00\x7e\x00", v4.AppendLittleEndian(nil)},
+	} {
+		b, err := tc.v.MarshalBinary()
+		if err != nil || string(b) != tc.big {
+			t.Errorf("%v.MarshalBinary = % x, %v, wanted % x", tc.v, b, err, tc.big)
+		}
+		if err := tc.u.UnmarshalBinary(b); err != nil {
+			t.Errorf("UnmarshalBinary(% x): %v", b, err)
+		}
+		for _, bad := range [][]byte{b[1:], append(b, 0, 0)} {
+			if err := tc.u.UnmarshalBinary(bad); err != float16.ErrInvalidVecLength {
+				t.Errorf("UnmarshalBinary(% x) = %v, wanted %v", bad, err, float16.ErrInvalidVecLength)
+			}
+		}
+
+		// Little-endian swaps the bytes of each component.
+		for i := 0; i < len(b); i += 2 {
+			if tc.little[i] != b[i+1] || tc.little[i+1] != b[i] {
+				t.Errorf("%v.AppendLittleEndian = % x", tc.v, tc.little)
+				break
+			}
+		}
+	}
+	var u2 float16.Vec2
+	var u3 float16.Vec3
+	var u4 float16.Vec4
+	if u2.UnmarshalBinary([]byte("\x3c\x00\x01\x02")); u2 != v2 {
+		t.Errorf("Vec2.UnmarshalBinary = %v, wanted %v", u2, v2)
+	}
+	if u3.UnmarshalBinary([]byte("\x3c\x00\x01\x02\xc0\x00")); u3 != v3 {
+		t.Errorf("Vec3.UnmarshalBinary = %v, wanted %v", u3, v3)
+	}
+	if u4.UnmarshalBinary([]byte("\x3c\x00\x01\x02\xc0\x00\x7e\x00")); u4 != v4 {
+		t.Errorf("Vec4.UnmarshalBinary = %v, wanted %v", u4, v4)
+	}
+
+	buf := []byte{0xff, 0x00, 0x3c, 0x02, 0x01, 0x00, 0xc0, 0x00, 0x7e}
+	if got := float16.Vec2FromLittleEndian(buf[1:]); got != v2 {
+		t.Errorf("Vec2FromLittleEndian = %v, wanted %v", got, v2)
+	}
+	if got := float16.Vec3FromLittleEndian(buf[1:]); got != v3 {
+		t.Errorf("Vec3FromLittleEndian = %v, wanted %v", got, v3)
+	}
+	if got := float16.Vec4FromLittleEndian(buf[1:]); got != v4 {
+		t.Errorf("Vec4FromLittleEndian = %v, wanted %v", got, v4)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Vec4FromLittleEndian of 7 bytes didn't panic")
+		}
+	}()
+	float16.Vec4FromLittleEndian(buf[2:])
+}